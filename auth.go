@@ -0,0 +1,48 @@
+package restkit
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Token is an access token issued by a TokenSource.
+type Token struct {
+	AccessToken string    // Token sent in the Authorization header
+	TokenType   string    // Optional type, defaults to "Bearer"
+	Expiry      time.Time // Optional expiry, informational only
+}
+
+// TokenSource supplies access tokens. Implementations are expected to cache and
+// refresh tokens themselves and must be safe for concurrent use.
+// Sources from golang.org/x/oauth2 can be adapted with TokenSourceFunc.
+type TokenSource interface {
+	Token() (*Token, error)
+}
+
+// TokenSourceFunc adapts an ordinary function to the TokenSource interface.
+type TokenSourceFunc func() (*Token, error)
+
+// Token calls f().
+func (f TokenSourceFunc) Token() (*Token, error) {
+	return f()
+}
+
+// authorize sets the Authorization header of req from the token source.
+func (c *Client) authorize(req *http.Request) error {
+	token, err := c.tokenSource.Token()
+	if err != nil {
+		return newInternalError("auth", err)
+	}
+	if token == nil {
+		return newInternalError("auth", ErrNilToken)
+	}
+
+	tokenType := token.TokenType
+	if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+	req.Header.Set("Authorization", tokenType+" "+token.AccessToken)
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package restkit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+func TestTokenSource(t *testing.T) {
+	var got []string
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer httpServer.Close()
+
+	tokens := []*rest.Token{
+		{AccessToken: "first", TokenType: "bearer"},
+		{AccessToken: "second", TokenType: "MAC"},
+	}
+	calls := 0
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		TokenSource: rest.TokenSourceFunc(func() (*rest.Token, error) {
+			token := tokens[calls]
+			calls++
+			return token, nil
+		}),
+	})
+
+	for range 2 {
+		if err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil); err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+	}
+
+	if len(got) != 2 || got[0] != "Bearer first" || got[1] != "MAC second" {
+		t.Errorf("Unexpected Authorization headers: %q", got)
+	}
+}
+
+func TestTokenSource_Error(t *testing.T) {
+	var requests int
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer httpServer.Close()
+
+	errExpired := errors.New("refresh token expired")
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		TokenSource: rest.TokenSourceFunc(func() (*rest.Token, error) {
+			return nil, errExpired
+		}),
+	})
+
+	err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+
+	var internalErr *rest.InternalError
+	if !errors.As(err, &internalErr) || internalErr.Op != "auth" || !errors.Is(err, errExpired) {
+		t.Errorf("Expected internal error with op auth, got %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("Expected no request without a token, got %d", requests)
+	}
+}
+
+func TestTokenSource_NilToken(t *testing.T) {
+	httpServer := setupTestServer(t)
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		TokenSource: rest.TokenSourceFunc(func() (*rest.Token, error) {
+			return nil, nil //nolint:nilnil // misbehaving source under test
+		}),
+	})
+
+	err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	if !rest.IsInternalError(err) || !errors.Is(err, rest.ErrNilToken) {
+		t.Errorf("Expected internal ErrNilToken error, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package restkit
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// BatchRequest describes a single request issued by DoBatch.
+type BatchRequest struct {
+	Method  string      // HTTP method
+	Path    string      // Request path, resolved against the base URL
+	Headers http.Header // Optional request headers
+	Payload any         // Optional payload, marshaled to JSON
+}
+
+// BatchResult holds the outcome of a single request issued by DoBatch.
+type BatchResult[T any] struct {
+	Index int   // Index of the request in the slice passed to DoBatch
+	Value T     // Decoded response, zero when Err is not nil
+	Err   error // Error of this request
+}
+
+// DoBatch performs reqs with at most concurrency requests in flight and returns
+// one result per request, in the order of reqs. A concurrency of zero or less
+// runs all requests at once.
+// Failures of individual requests are reported in their results only.
+// Once ctx is canceled no new requests are issued: their results carry an
+// InfrastructureError and the first of these errors is also returned.
+func DoBatch[T any](ctx context.Context, c *Client, reqs []BatchRequest, concurrency int) ([]BatchResult[T], error) {
+	if concurrency <= 0 || concurrency > len(reqs) {
+		concurrency = len(reqs)
+	}
+
+	results := make([]BatchResult[T], len(reqs))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				req := reqs[i]
+				results[i].Err = c.Do(ctx, req.Method, req.Path, req.Headers, req.Payload, &results[i].Value)
+			}
+		}()
+	}
+
+	var err error
+	for i, req := range reqs {
+		results[i].Index = i
+		if err != nil {
+			results[i].Err = newInfrastructureError(req.Path, ctx.Err())
+			continue
+		}
+
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			err = newInfrastructureError(req.Path, ctx.Err())
+			results[i].Err = err
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i := range results {
+		if results[i].Err != nil {
+			var zero T
+			results[i].Value = zero
+		}
+	}
+
+	return results, err
+}
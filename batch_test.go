@@ -0,0 +1,104 @@
+package restkit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+func TestDoBatch(t *testing.T) {
+	var inflight, peak atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		id := strings.TrimPrefix(r.URL.Path, "/items/")
+		if id == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": "not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": "` + id + `"}`))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	ids := []string{"a", "b", "missing", "c", "d", "e"}
+	reqs := make([]rest.BatchRequest, len(ids))
+	for i, id := range ids {
+		reqs[i] = rest.BatchRequest{Method: http.MethodGet, Path: "/items/" + id}
+	}
+
+	type item struct {
+		ID string `json:"id"`
+	}
+	results, err := rest.DoBatch[item](context.Background(), client, reqs, 2)
+	if err != nil {
+		t.Fatalf("DoBatch() error = %v", err)
+	}
+
+	if len(results) != len(ids) {
+		t.Fatalf("Expected %d results, got %d", len(ids), len(results))
+	}
+	for i, res := range results {
+		if res.Index != i {
+			t.Errorf("Expected index %d, got %d", i, res.Index)
+		}
+		if ids[i] == "missing" {
+			if !rest.IsClientError(res.Err) {
+				t.Errorf("Expected client error for %q, got %v", ids[i], res.Err)
+			}
+			continue
+		}
+		if res.Err != nil || res.Value.ID != ids[i] {
+			t.Errorf("Expected %q, got %+v", ids[i], res)
+		}
+	}
+
+	if got := peak.Load(); got > 2 {
+		t.Errorf("Expected at most 2 concurrent requests, got %d", got)
+	}
+}
+
+func TestDoBatch_Canceled(t *testing.T) {
+	var requests atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	reqs := make([]rest.BatchRequest, 5)
+	for i := range reqs {
+		reqs[i] = rest.BatchRequest{Method: http.MethodGet, Path: "/"}
+	}
+
+	results, err := rest.DoBatch[map[string]any](ctx, client, reqs, 1)
+	if !rest.IsInfrastructureError(err) {
+		t.Errorf("Expected infrastructure error, got %v", err)
+	}
+	for _, res := range results {
+		if res.Err == nil {
+			t.Errorf("Expected result %d to fail", res.Index)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package restkit
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// defaultMaxReplayBytes is the default limit for buffering request bodies for replay.
+const defaultMaxReplayBytes = 10 << 20 // 10 MiB
+
+// maxDecodeErrorBody limits how much of a response body is kept in a DecodeError.
+const maxDecodeErrorBody = 8 << 10 // 8 KiB
+
+// bufferBody reads a one-shot request body into memory and sets GetBody
+// so that the body can be sent more than once.
+// Bodies larger than limit are left streaming and remain one-shot; a
+// non-positive limit buffers the whole body.
+func bufferBody(req *http.Request, limit int64) error {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return nil
+	}
+
+	reader := io.Reader(req.Body)
+	if limit > 0 {
+		reader = io.LimitReader(req.Body, limit+1)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		req.Body.Close()
+		return fmt.Errorf("failed to read body: %w", err)
+	}
+
+	if limit > 0 && int64(len(data)) > limit {
+		// Too large to buffer: stitch the consumed prefix back in front of the stream.
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), req.Body), req.Body}
+		return nil
+	}
+	req.Body.Close()
+
+	req.ContentLength = int64(len(data))
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	return nil
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the rest.
+type limitedBuffer struct {
+	buf   []byte
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if n := b.remaining(); n > 0 {
+		b.buf = append(b.buf, p[:min(len(p), n)]...)
+	}
+	return len(p), nil
+}
+
+// remaining returns how many more bytes the buffer keeps.
+func (b *limitedBuffer) remaining() int {
+	return b.limit - len(b.buf)
+}
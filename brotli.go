@@ -0,0 +1,16 @@
+//go:build brotli
+
+package restkit
+
+import (
+	"io"
+
+	"github.com/andybalholm/brotli"
+)
+
+// brotliDecompressor returns the decoder of the "br" content encoding.
+func brotliDecompressor() decompressor {
+	return func(r io.Reader) (io.ReadCloser, error) {
+		return io.NopCloser(brotli.NewReader(r)), nil
+	}
+}
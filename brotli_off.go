@@ -0,0 +1,8 @@
+//go:build !brotli
+
+package restkit
+
+// brotliDecompressor returns nil: brotli support requires the `brotli` build tag.
+func brotliDecompressor() decompressor {
+	return nil
+}
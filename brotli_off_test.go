@@ -0,0 +1,11 @@
+//go:build !brotli
+
+package restkit_test
+
+import "io"
+
+const brotliSupported = false
+
+func brotliEncoder(io.Writer, string) io.WriteCloser {
+	return nil
+}
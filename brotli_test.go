@@ -0,0 +1,18 @@
+//go:build brotli
+
+package restkit_test
+
+import (
+	"io"
+
+	"github.com/andybalholm/brotli"
+)
+
+const brotliSupported = true
+
+func brotliEncoder(w io.Writer, encoding string) io.WriteCloser {
+	if encoding != "br" {
+		return nil
+	}
+	return brotli.NewWriter(w)
+}
@@ -0,0 +1,72 @@
+package restkit
+
+import "sync"
+
+// defaultBudgetTokenRatio is the default number of tokens a successful request adds.
+const defaultBudgetTokenRatio = 0.1
+
+// RetryBudget throttles retries with a token bucket shared by all requests of
+// a client, similar to gRPC retry throttling, so that retries do not amplify
+// load during an outage. The bucket starts full. Every retry that would
+// otherwise be attempted removes a token and every successful request adds
+// TokenRatio tokens. Retries are only attempted while more than MinTokens
+// tokens remain.
+type RetryBudget struct {
+	MaxTokens  float64 // Size of the bucket, zero disables the budget
+	MinTokens  float64 // Optional threshold for retries, defaults to half of MaxTokens
+	TokenRatio float64 // Optional tokens added by a successful request, defaults to 0.1
+}
+
+// retryBudget is the shared state of a RetryBudget.
+type retryBudget struct {
+	mu     sync.Mutex
+	tokens float64
+	max    float64
+	min    float64
+	ratio  float64
+}
+
+// newRetryBudget returns the state of config, or nil when the budget is disabled.
+func newRetryBudget(config RetryBudget) *retryBudget {
+	if config.MaxTokens <= 0 {
+		return nil
+	}
+
+	budget := &retryBudget{
+		mu:     sync.Mutex{},
+		tokens: config.MaxTokens,
+		max:    config.MaxTokens,
+		min:    config.MinTokens,
+		ratio:  config.TokenRatio,
+	}
+	if budget.min <= 0 {
+		budget.min = config.MaxTokens / 2 //nolint:mnd // half of the bucket as in gRPC
+	}
+	if budget.ratio <= 0 {
+		budget.ratio = defaultBudgetTokenRatio
+	}
+	return budget
+}
+
+// deposit records a successful request.
+func (b *retryBudget) deposit() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.tokens+b.ratio, b.max)
+}
+
+// withdraw records a failure about to be retried and reports whether the retry is allowed.
+func (b *retryBudget) withdraw() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = max(b.tokens-1, 0)
+	return b.tokens > b.min
+}
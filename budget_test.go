@@ -0,0 +1,142 @@
+package restkit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+func TestRetry_Budget(t *testing.T) {
+	var (
+		attempts atomic.Int32
+		healthy  atomic.Bool
+	)
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		Retry: rest.RetryConfig{
+			MaxAttempts: 3,
+			Backoff:     constantBackoff(time.Millisecond),
+			Budget:      rest.RetryBudget{MaxTokens: 4, TokenRatio: 1},
+		},
+	})
+
+	call := func() int32 {
+		attempts.Store(0)
+		_ = client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+		return attempts.Load()
+	}
+
+	// 4 tokens, retries need more than 2.
+	if got := call(); got != 2 {
+		t.Errorf("Expected 2 attempts with a full budget, got %d", got)
+	}
+	if got := call(); got != 1 {
+		t.Errorf("Expected no retries with an exhausted budget, got %d attempts", got)
+	}
+
+	healthy.Store(true)
+	for range 3 {
+		if got := call(); got != 1 {
+			t.Errorf("Expected a single attempt for a successful request, got %d", got)
+		}
+	}
+
+	healthy.Store(false)
+	if got := call(); got != 2 {
+		t.Errorf("Expected retries once the budget is replenished, got %d attempts", got)
+	}
+}
+
+func TestRetry_BudgetDisabled(t *testing.T) {
+	var attempts atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		Retry:   rest.RetryConfig{MaxAttempts: 3, Backoff: constantBackoff(time.Millisecond)},
+	})
+
+	for range 5 {
+		_ = client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	}
+	if got := attempts.Load(); got != 15 {
+		t.Errorf("Expected every request to be retried without a budget, got %d attempts", got)
+	}
+}
+
+func TestRetry_BudgetNotSpentWithoutRetry(t *testing.T) {
+	var attempts atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		Retry: rest.RetryConfig{
+			MaxAttempts: 2,
+			Backoff:     constantBackoff(time.Millisecond),
+			Budget:      rest.RetryBudget{MaxTokens: 5, TokenRatio: 1},
+		},
+	})
+
+	// 5 tokens, retries need more than 2.5. Only the two retries spend a token,
+	// not the failures that exhaust MaxAttempts.
+	for range 2 {
+		_ = client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	}
+	if got := attempts.Load(); got != 4 {
+		t.Errorf("Expected both requests to be retried, got %d attempts", got)
+	}
+}
+
+func TestRetry_BudgetSharedWithOverride(t *testing.T) {
+	var attempts atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		Retry: rest.RetryConfig{
+			MaxAttempts: 2,
+			Backoff:     constantBackoff(time.Millisecond),
+			Budget:      rest.RetryBudget{MaxTokens: 2, TokenRatio: 1},
+		},
+	})
+
+	// 2 tokens, retries need more than 1: the first failure exhausts the budget.
+	_ = client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+
+	attempts.Store(0)
+	ctx := rest.WithRetryOverride(context.Background(), rest.RetryConfig{
+		MaxAttempts: 3,
+		Backoff:     constantBackoff(time.Millisecond),
+	})
+	_ = client.Do(ctx, http.MethodGet, "/", nil, nil, nil)
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("Expected the override to draw from the exhausted client budget, got %d attempts", got)
+	}
+}
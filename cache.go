@@ -0,0 +1,258 @@
+package restkit
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultCacheTTL is the lifetime of cached responses when Config.CacheTTL is not set.
+const defaultCacheTTL = time.Minute
+
+// revalidationTTL is how long a response carrying validators is kept past its
+// lifetime so that it can be revalidated with a conditional request.
+const revalidationTTL = time.Hour
+
+// Cache stores encoded responses for reuse by later requests. Values are
+// opaque to the cache and must be returned unchanged.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value stored under key, or false if there is none or it expired.
+	Get(key string) ([]byte, bool)
+	// Set stores value under key for ttl. A ttl of zero or less never expires.
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// bypassCacheKey marks the context of requests that must reach the server,
+// bypassing the cache and the request deduplication.
+type bypassCacheKey struct{}
+
+// withoutCache returns a copy of ctx whose requests bypass the cache and the
+// request deduplication.
+func withoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheKey{}, true)
+}
+
+// bypassesCache reports whether requests made with ctx bypass the cache.
+func bypassesCache(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassCacheKey{}).(bool)
+	return bypass
+}
+
+// cacheEntry is a cached response body together with its Content-Type, which
+// selects the decoder of the body when it is served from the cache, and its
+// validators, used to revalidate the body once it expired.
+type cacheEntry struct {
+	ContentType  string    `json:"contentType,omitempty"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	Expires      time.Time `json:"expires,omitzero"`
+	Body         []byte    `json:"body"`
+}
+
+// fresh reports whether the entry can be served without contacting the server.
+func (e cacheEntry) fresh(now time.Time) bool {
+	return e.Expires.IsZero() || now.Before(e.Expires)
+}
+
+// revalidatable reports whether the entry carries validators.
+func (e cacheEntry) revalidatable() bool {
+	return e.ETag != "" || e.LastModified != ""
+}
+
+// sendCached serves req from the cache when possible and otherwise sends it,
+// storing the body of a successful response.
+// Responses carrying an ETag or Last-Modified header are revalidated with a
+// conditional request once their entry expires; a 304 Not Modified response is
+// then served from the stored body.
+func (c *Client) sendCached(req *http.Request, response any) (*Response, error) {
+	key := req.URL.String()
+
+	entry, ok := c.cachedEntry(key)
+	if ok && entry.fresh(time.Now()) {
+		header := http.Header{}
+		if entry.ContentType != "" {
+			header.Set("Content-Type", entry.ContentType)
+		}
+		meta := &Response{
+			StatusCode: http.StatusOK,
+			Header:     header,
+			RateLimit:  nil,
+			Duration:   0,
+			Attempts:   0,
+			FromCache:  true,
+			URL:        key,
+			Proto:      "",
+			TLS:        nil,
+		}
+		return meta, c.decodeCached(entry.Body, entry.ContentType, response)
+	}
+
+	revalidate := ok && entry.revalidatable()
+	if revalidate {
+		if entry.ETag != "" && req.Header.Get("If-None-Match") == "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" && req.Header.Get("If-Modified-Since") == "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	meta, body, err := c.transmitBody(req)
+	if err != nil {
+		return meta, err
+	}
+
+	contentType := meta.Header.Get("Content-Type")
+	switch {
+	case meta.StatusCode == http.StatusNotModified && revalidate:
+		body, contentType = entry.Body, entry.ContentType
+		c.storeEntry(key, entry)
+		if contentType != "" {
+			meta.Header.Set("Content-Type", contentType)
+		}
+		meta.FromCache = true
+	case meta.StatusCode >= http.StatusOK && meta.StatusCode < http.StatusMultipleChoices:
+		c.storeEntry(key, cacheEntry{
+			ContentType:  contentType,
+			ETag:         meta.Header.Get("ETag"),
+			LastModified: meta.Header.Get("Last-Modified"),
+			Expires:      time.Time{},
+			Body:         body,
+		})
+	}
+
+	return meta, c.decodeCached(body, contentType, response)
+}
+
+// cachedEntry returns the response cached under key. Entries that cannot be
+// parsed are treated as missing.
+func (c *Client) cachedEntry(key string) (cacheEntry, bool) {
+	var entry cacheEntry
+
+	data, ok := c.cache.Get(key)
+	if !ok {
+		return entry, false
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return entry, false
+	}
+
+	return entry, true
+}
+
+// storeEntry caches entry under key for the cache TTL. Entries carrying
+// validators are kept for revalidationTTL longer, so that they can still be
+// revalidated after they expired.
+func (c *Client) storeEntry(key string, entry cacheEntry) {
+	ttl := c.cacheTTL
+	entry.Expires = time.Now().Add(ttl)
+	if entry.revalidatable() {
+		ttl += revalidationTTL
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	c.cache.Set(key, data, ttl)
+}
+
+// decodeCached decodes a buffered response body into response.
+func (c *Client) decodeCached(body []byte, contentType string, response any) error {
+	if response == nil {
+		return nil
+	}
+	if err := c.decode(bytes.NewReader(body), contentType, response); err != nil {
+		return newInternalError("DoRAW", err)
+	}
+	return nil
+}
+
+// LRUCache is an in-memory Cache that evicts the least recently used entry
+// once it holds the maximum number of entries.
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+// lruEntry is an element of the LRUCache recency list.
+type lruEntry struct {
+	key     string
+	body    []byte
+	expires time.Time
+}
+
+// NewLRUCache returns an LRUCache holding at most capacity entries.
+// A capacity of zero or less means the cache is unbounded.
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{
+		mu:       sync.Mutex{},
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get implements Cache.
+func (l *LRUCache) Get(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*lruEntry) //nolint:errcheck,forcetypeassert // only *lruEntry is stored
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		l.order.Remove(elem)
+		delete(l.entries, key)
+		return nil, false
+	}
+
+	l.order.MoveToFront(elem)
+	return entry.body, true
+}
+
+// Set implements Cache.
+func (l *LRUCache) Set(key string, body []byte, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+
+	if elem, ok := l.entries[key]; ok {
+		entry := elem.Value.(*lruEntry) //nolint:errcheck,forcetypeassert // only *lruEntry is stored
+		entry.body = body
+		entry.expires = expires
+		l.order.MoveToFront(elem)
+		return
+	}
+
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, body: body, expires: expires})
+
+	if l.capacity > 0 && l.order.Len() > l.capacity {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*lruEntry).key) //nolint:errcheck,forcetypeassert // only *lruEntry is stored
+	}
+}
+
+// Len returns the number of entries in the cache, including expired ones
+// that have not been evicted yet.
+func (l *LRUCache) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.order.Len()
+}
@@ -0,0 +1,272 @@
+package restkit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+func TestCache(t *testing.T) {
+	var requests atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"path": "` + r.URL.Path + `"}`))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		Cache:   rest.NewLRUCache(10),
+	})
+
+	type result struct {
+		Path string `json:"path"`
+	}
+
+	for range 3 {
+		var resp result
+		if err := client.Do(context.Background(), http.MethodGet, "/items", nil, nil, &resp); err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		if resp.Path != "/items" {
+			t.Errorf("Expected path %q, got %q", "/items", resp.Path)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected a single request for cached GETs, got %d", got)
+	}
+
+	for range 2 {
+		_ = client.Do(context.Background(), http.MethodGet, "/missing", nil, nil, nil)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("Expected error responses not to be cached, got %d requests", got)
+	}
+
+	for range 2 {
+		_ = client.Do(context.Background(), http.MethodPut, "/items", nil, map[string]string{}, nil)
+	}
+	if got := requests.Load(); got != 5 {
+		t.Errorf("Expected non-GET requests not to be cached, got %d requests", got)
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	var requests atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL:  httpServer.URL,
+		Cache:    rest.NewLRUCache(10),
+		CacheTTL: 20 * time.Millisecond,
+	})
+
+	_ = client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	_ = client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	time.Sleep(30 * time.Millisecond)
+	_ = client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Expected the entry to expire after the TTL, got %d requests", got)
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	cache := rest.NewLRUCache(2)
+
+	cache.Set("a", []byte("1"), 0)
+	cache.Set("b", []byte("2"), 0)
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("Expected entry a")
+	}
+	cache.Set("c", []byte("3"), 0)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("Expected least recently used entry b to be evicted")
+	}
+	if body, ok := cache.Get("a"); !ok || string(body) != "1" {
+		t.Errorf("Expected entry a to be kept, got %q, %v", body, ok)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", cache.Len())
+	}
+
+	cache.Set("c", []byte("4"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok := cache.Get("c"); ok {
+		t.Error("Expected entry c to expire")
+	}
+}
+
+func TestCache_Revalidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator string
+		value     string
+		condition string
+	}{
+		{name: "etag", validator: "ETag", value: `"v1"`, condition: "If-None-Match"},
+		{name: "last modified", validator: "Last-Modified", value: "Mon, 02 Jan 2006 15:04:05 GMT", condition: "If-Modified-Since"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var full, notModified atomic.Int32
+			httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(tt.validator, tt.value)
+				if r.Header.Get(tt.condition) == tt.value {
+					notModified.Add(1)
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				full.Add(1)
+				_, _ = w.Write([]byte(`{"name": "large resource"}`))
+			}))
+			defer httpServer.Close()
+
+			client, _ := rest.NewClient(rest.Config{
+				BaseURL:  httpServer.URL,
+				Cache:    rest.NewLRUCache(10),
+				CacheTTL: time.Nanosecond,
+			})
+
+			for range 3 {
+				time.Sleep(time.Millisecond)
+
+				var resp struct {
+					Name string `json:"name"`
+				}
+				if err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, &resp); err != nil {
+					t.Fatalf("Do() error = %v", err)
+				}
+				if resp.Name != "large resource" {
+					t.Errorf("Expected cached body to be decoded, got %q", resp.Name)
+				}
+			}
+
+			if full.Load() != 1 || notModified.Load() != 2 {
+				t.Errorf("Expected 1 full and 2 not modified responses, got %d and %d", full.Load(), notModified.Load())
+			}
+		})
+	}
+}
+
+// ttlCache is a Cache recording the TTL of every stored key.
+type ttlCache struct {
+	*rest.LRUCache
+
+	mu   sync.Mutex
+	ttls map[string]time.Duration
+}
+
+func (c *ttlCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	c.ttls[key] = ttl
+	c.mu.Unlock()
+	c.LRUCache.Set(key, value, ttl)
+}
+
+func TestCache_RevalidationEntry(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte(`{"name": "large resource"}`))
+	}))
+	defer httpServer.Close()
+
+	cache := &ttlCache{LRUCache: rest.NewLRUCache(0), mu: sync.Mutex{}, ttls: map[string]time.Duration{}}
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL:  httpServer.URL,
+		Cache:    cache,
+		CacheTTL: time.Nanosecond,
+	})
+
+	for range 2 {
+		time.Sleep(time.Millisecond)
+		if err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil); err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+	}
+
+	if cache.Len() != 1 {
+		t.Errorf("Expected a single cache entry, got %d", cache.Len())
+	}
+	for key, ttl := range cache.ttls {
+		if ttl <= 0 {
+			t.Errorf("Expected entry %q to expire, got TTL %v", key, ttl)
+		}
+	}
+}
+
+func TestCache_ContentType(t *testing.T) {
+	tests := []struct {
+		name         string
+		cacheTTL     time.Duration
+		wantRequests int32
+	}{
+		{name: "hit", cacheTTL: time.Minute, wantRequests: 1},
+		{name: "revalidated", cacheTTL: time.Nanosecond, wantRequests: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.Header().Set("ETag", `"v1"`)
+				if r.Header.Get("If-None-Match") == `"v1"` {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set("Content-Type", rest.CSVContentType)
+				_, _ = w.Write([]byte("name\nwidget\n"))
+			}))
+			defer httpServer.Close()
+
+			client, _ := rest.NewClient(rest.Config{
+				BaseURL:  httpServer.URL,
+				Cache:    rest.NewLRUCache(10),
+				CacheTTL: tt.cacheTTL,
+			})
+
+			for range 2 {
+				time.Sleep(time.Millisecond)
+
+				var rows []struct {
+					Name string `csv:"name"`
+				}
+				meta, err := client.DoWithResponse(context.Background(), http.MethodGet, "/items.csv", nil, nil, &rows)
+				if err != nil {
+					t.Fatalf("DoWithResponse() error = %v", err)
+				}
+				if len(rows) != 1 || rows[0].Name != "widget" {
+					t.Errorf("Expected the CSV body to be decoded, got %+v", rows)
+				}
+				if meta.FromCache && meta.Header.Get("Content-Type") != rest.CSVContentType {
+					t.Errorf("Expected the cached Content-Type, got %q", meta.Header.Get("Content-Type"))
+				}
+			}
+
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("Expected %d requests, got %d", tt.wantRequests, got)
+			}
+		})
+	}
+}
@@ -4,47 +4,424 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
 )
 
+// Config configures a Client.
+//
+// Request paths are resolved against BaseURL following RFC 3986, like links in a
+// web page: a path without a leading slash replaces the last segment of the base
+// path, so `users` against `https://api.example.com/v1` yields `/users`, while
+// against `https://api.example.com/v1/` it yields `/v1/users`. A path with a
+// leading slash replaces the whole base path and an absolute URL replaces the
+// base URL. Set BasePath to have a prefix applied to every request path instead.
 type Config struct {
-	Client  *http.Client // Optional HTTP Client, defaults to `http.DefaultClient`
-	BaseURL string       // Optional base URL
+	Client  *http.Client // Optional HTTP Client, defaults to `http.DefaultClient` or a client built from the transport settings below
+	BaseURL string       // Optional base URL, request paths are resolved against it as described above
+	Headers http.Header  // Optional default headers sent with every request
+
+	// DefaultQuery is added to the query of every request, e.g. an `api-version`
+	// parameter. Parameters already present in the request path take precedence.
+	// DoRawPath sends its URL unchanged.
+	DefaultQuery url.Values
+
+	Retry   RetryConfig // Optional retry policy, retries are disabled by default
+	CSRF    *CSRFConfig // Optional CSRF token handling for unsafe methods
+	Metrics Metrics     // Optional metrics sink, observations are discarded by default
+
+	// BasePath is prefixed to every request path that is not an absolute URL, with
+	// or without a leading slash, before it is resolved against BaseURL. The path
+	// of BaseURL itself is then ignored: `/v1` with the path `/users` or `users`
+	// always yields `/v1/users`.
+	BasePath string
+
+	// Middlewares wrap every attempt of a request right before it is sent, after
+	// headers and body are finalized. The first middleware is the outermost one.
+	Middlewares []Middleware
+
+	// SignRequest is called for every attempt right before the request is sent,
+	// after default headers are applied, with the final body bytes.
+	// Returning an error aborts the request with an InternalError.
+	SignRequest func(req *http.Request, body []byte) error
+
+	// OnUnauthorized is called when a request fails with 401 to refresh credentials,
+	// after which the request is sent once more. The refreshed credentials must be
+	// applied by a middleware, SignRequest or similar hook, since the retried request
+	// is built from the original one.
+	OnUnauthorized func(ctx context.Context) error
+
+	// MaxReplayBytes limits how much of a one-shot DoRAW body is buffered in memory
+	// so that it can be resent by retries, re-authentication or CSRF refresh.
+	// Larger bodies are streamed and sent only once. Defaults to 10 MiB.
+	MaxReplayBytes int64
+
+	// Connection pool settings. When any of them is set and Client is nil, a
+	// dedicated transport is built from `http.DefaultTransport` with these values.
+	// They are ignored when Client is supplied.
+	MaxIdleConns        int           // Maximum idle connections across all hosts
+	MaxIdleConnsPerHost int           // Maximum idle connections per host, net/http defaults to 2
+	MaxConnsPerHost     int           // Maximum connections per host including active ones
+	IdleConnTimeout     time.Duration // How long an idle connection is kept
+
+	// Timeouts of the individual phases of a request, unlike the overall
+	// http.Client timeout. Zero keeps the `http.DefaultTransport` behavior.
+	// Like the pool settings, they build a dedicated transport and are ignored
+	// when Client is supplied.
+	DialTimeout           time.Duration // Maximum time to establish a connection
+	TLSHandshakeTimeout   time.Duration // Maximum time of the TLS handshake
+	ResponseHeaderTimeout time.Duration // Maximum time to wait for response headers after the request is written
+
+	// MaxResponseHeaderBytes limits the size of response headers, guarding against
+	// servers sending enormous headers. Zero keeps the net/http default of 1 MiB.
+	// Like the pool settings, it builds a dedicated transport and is ignored when
+	// Client is supplied.
+	MaxResponseHeaderBytes int64
+
+	// ForceHTTP2 makes the default transport speak only HTTP/2: negotiated via ALPN
+	// for https and with prior knowledge (h2c) for plain http URLs.
+	// Ignored when Client is supplied.
+	ForceHTTP2 bool
+
+	// UnixSocket makes the default transport dial this Unix domain socket instead
+	// of the host in the request URL. BaseURL may then be a placeholder such as
+	// `http://localhost`, or be left empty. A base URL of the form
+	// `unix:///path/to/app.sock` sets this field too. Ignored when Client is supplied.
+	UnixSocket string
+
+	// Proxy routes requests of the default transport through this proxy instead of
+	// the one from the HTTP_PROXY/HTTPS_PROXY environment variables. Credentials in
+	// the URL userinfo are sent as Proxy-Authorization. Ignored when Client is supplied.
+	Proxy *url.URL
+
+	// Hedging sends additional copies of slow safe requests to reduce tail latency.
+	// Disabled by default.
+	Hedging HedgingConfig
+
+	// Cache stores bodies of successful GET responses with their Content-Type,
+	// keyed by the resolved URL. GET requests with a body bypass the cache.
+	// Cached responses are decoded like the original response without
+	// contacting the server. Responses with an ETag or Last-Modified header
+	// are kept for an hour after they expired and revalidated with a
+	// conditional request.
+	// Disabled when nil.
+	Cache    Cache
+	CacheTTL time.Duration // Optional lifetime of cached responses, defaults to 1 minute
+
+	// Singleflight collapses concurrent GET requests for the same resolved URL into
+	// a single round trip. Request headers are not part of the key. Every caller
+	// decodes its own copy and stops waiting when its own context is done; when
+	// the caller sending the shared request is canceled, the others send it again.
+	Singleflight bool
+
+	// RequestEditors are called in order with every request once it is built and
+	// before it is sent. An error aborts the call with an InternalError.
+	RequestEditors []RequestEditorFn
+
+	// ResponseValidators are called in order with every successful response before
+	// its body is decoded. An error rejects the response with an InternalError.
+	ResponseValidators []ResponseValidatorFn
+
+	// ResponseErrorCheck is called with the status code and the body of every
+	// successful response, after the ResponseValidators, to turn errors reported
+	// in the body of envelope-style APIs into errors. An APIError it returns is
+	// passed on, with StatusCode, URL, Body and ContentType filled from the
+	// response when left empty, so AsAPIError finds it. Other errors are wrapped
+	// in an InternalError with op "check".
+	ResponseErrorCheck func(status int, body []byte) error
+
+	// PropagateTraceContext sets the `traceparent` and `tracestate` headers from the
+	// TraceContext stored in the request context with WithTraceContext.
+	// Tracers such as OpenTelemetry can inject their propagator via RequestEditors instead.
+	PropagateTraceContext bool
+
+	// CorrelationIDHeader names the header, e.g. `X-Correlation-Id`, that is set to
+	// the correlation ID stored in the request context with WithCorrelationID.
+	// Requests that already carry the header are left unchanged. Disabled when empty.
+	CorrelationIDHeader   string
+	GenerateCorrelationID bool // Optional, generates a random ID when the context has none
+
+	// OnResponse is called with every raw response, including retried attempts and
+	// error responses, before its body is read. It gives access to details such as
+	// the TLS state or the request that was sent. The callback must neither read nor
+	// close the body; the client consumes and closes it afterwards. Trailers are only
+	// populated once the body has been read, so they are not available here.
+	OnResponse func(resp *http.Response)
+
+	// OnError is called once for every call that ends with an error, be it an
+	// InternalError, an InfrastructureError or an APIError, with the method and
+	// the resolved URL of the request. It suits central error reporting.
+	OnError func(ctx context.Context, method, url string, err error)
+
+	// Debug dumps every attempt's request and response, headers and body, to
+	// DebugOutput. Authorization, cookie and API key headers are redacted. Bodies
+	// are buffered in memory to be dumped, so it is meant for troubleshooting only.
+	Debug       bool
+	DebugOutput io.Writer // Optional, defaults to os.Stderr
+
+	// Compression advertises every supported content encoding in `Accept-Encoding`:
+	// gzip, deflate and, when built with the `brotli` tag, br. Response bodies are
+	// decoded according to `Content-Encoding` regardless of this setting, and an
+	// unsupported encoding fails with an InternalError.
+	Compression bool
+
+	// TokenSource supplies the access token set as the `Authorization` header of
+	// every attempt, replacing any header set otherwise. Failing to obtain a token
+	// aborts the call with an InternalError with op "auth".
+	TokenSource TokenSource
+
+	// IsSuccess reports whether a status code is a successful response.
+	// Other status codes produce an APIError. Defaults to any status below 400.
+	IsSuccess func(statusCode int) bool
+
+	// MaxRedirects caps the number of redirects followed. Zero keeps the policy of
+	// the HTTP client when it has one and otherwise follows up to 10 redirects.
+	// NoRedirects disables following redirects so that the 3xx response is handled
+	// according to IsSuccess. Unless the HTTP client's own policy is kept,
+	// Authorization and cookie headers are stripped on redirects to another scheme
+	// or host, redirects from https to http fail with ErrInsecureRedirect, and
+	// exceeding the limit fails with ErrTooManyRedirects. Both are reported as
+	// an InternalError and are not retried.
+	MaxRedirects int
+
+	// AllowInsecureRedirect permits following redirects from https to http.
+	AllowInsecureRedirect bool
+
+	// IdempotencyKey generates an `Idempotency-Key` header for unsafe methods
+	// (all methods except GET, HEAD, OPTIONS and TRACE). The same key is reused
+	// across retries of a single call. A key supplied by the caller takes precedence.
+	IdempotencyKey bool
+
+	// MaxResponseBytes limits the size of successful response bodies that are decoded.
+	// Zero means unlimited.
+	MaxResponseBytes int64
+
+	// RequestSchema validates the encoded payload of Do before it is sent. A
+	// payload that does not conform fails with an InternalError matching
+	// ErrSchemaViolation and wrapping the validation failures.
+	RequestSchema SchemaValidator
+
+	// MaxRequestBytes rejects marshaled payloads larger than this many bytes with an
+	// InternalError before anything is sent. Zero means no limit.
+	MaxRequestBytes int64
+
+	// DisallowUnknownFields makes decoding fail when the response contains
+	// fields absent from the target struct. Useful to detect contract drift.
+	DisallowUnknownFields bool
+
+	// UseNumber decodes numbers into json.Number instead of float64 when the
+	// target is an interface value, preserving precision of large integers.
+	UseNumber bool
+
+	// UnwrapField names the top-level field of the JSON envelope holding the data
+	// of successful responses, e.g. "data" for `{"data": {...}}`. When set, that
+	// field is decoded into the response instead of the whole body, and a body
+	// without it fails with a DecodeError matching ErrEnvelopeField.
+	UnwrapField string
+
+	// UnwrapErrorField names the top-level field of the JSON envelope holding the
+	// error details of error responses, e.g. "error" for `{"error": {...}}`.
+	// APIError.ParseError then decodes that field and fails with ErrEnvelopeField
+	// when the body lacks it.
+	UnwrapErrorField string
+
+	// DisableBodyDrain closes response bodies without reading what is left of
+	// them, such as the whole body when response is nil. Draining, the default,
+	// lets the connection be reused for the next request; skipping it saves the
+	// bandwidth of large unwanted bodies but forces a new connection.
+	DisableBodyDrain bool
+
+	// DisableDefaultAccept stops Do from setting the default Accept header, that of
+	// the codec, when the request has no Accept header.
+	DisableDefaultAccept bool
+
+	// Codec encodes the payloads of Do and sets their default Content-Type and
+	// Accept headers. Responses of its media type, or without a Content-Type, are
+	// decoded with it. Marshal takes precedence for payloads. Defaults to JSONCodec.
+	Codec Codec
+
+	// NormalizePaths collapses duplicate slashes and resolves `.` and `..` segments
+	// in request paths before they are resolved against the base URL.
+	NormalizePaths bool
+
+	// Marshal, Unmarshal and NewDecoder replace encoding/json, e.g. with a faster
+	// drop-in implementation. DisallowUnknownFields and UseNumber only configure
+	// the default decoder.
+	Marshal    func(v any) ([]byte, error)    // Optional payload encoder, defaults to the codec
+	Unmarshal  func(data []byte, v any) error // Optional decoder of buffered values, defaults to json.Unmarshal
+	NewDecoder func(r io.Reader) JSONDecoder  // Optional decoder of response streams, defaults to json.NewDecoder
+
+	// Accept lists the media types accepted by Do in order of preference. They are
+	// sent as a quality-valued Accept header, e.g. `application/json, text/csv;q=0.9`,
+	// instead of `Accept: application/json`.
+	Accept []string
+
+	// Decoders selects the decoder of a successful response by the media type of
+	// its Content-Type header. JSON responses and responses without a Content-Type
+	// use the JSON decoder and `text/csv` responses CSVDecoder. When Accept or
+	// Decoders is set, other media types without a decoder fail with
+	// ErrUnsupportedMediaType.
+	Decoders map[string]ResponseDecoder
 }
 
 type Client struct {
-	client  *http.Client
-	baseURL *url.URL
+	client    *http.Client
+	transport *http.Transport // transport built by NewClient, nil when shared or supplied
+
+	maxRedirects          int
+	allowInsecureRedirect bool
+	baseURL               *url.URL
+	basePath              string
+	headers               http.Header
+	query                 url.Values
+	retry                 retryPolicy
+	csrf                  *csrfState
+	metrics               Metrics
+	inFlight              InFlightMetrics
+
+	middlewares []Middleware
+	signRequest func(req *http.Request, body []byte) error
+
+	onUnauthorized func(ctx context.Context) error
+	maxReplayBytes int64
+
+	isSuccess func(statusCode int) bool
+
+	idempotencyKey        bool
+	maxResponseBytes      int64
+	maxRequestBytes       int64
+	requestSchema         SchemaValidator
+	disallowUnknownFields bool
+	useNumber             bool
+	disableDefaultAccept  bool
+	disableBodyDrain      bool
+	accept                string
+	decoders              map[string]ResponseDecoder
+	normalizePaths        bool
+	unwrapField           string
+	unwrapErrorField      string
+
+	marshal        func(v any) ([]byte, error)
+	unmarshal      func(data []byte, v any) error
+	newJSONDecoder func(r io.Reader) JSONDecoder
+	codec          Codec
+
+	hedging HedgingConfig
+
+	cache    Cache
+	cacheTTL time.Duration
+	flights  *flightGroup
+
+	requestEditors     []RequestEditorFn
+	responseValidators []ResponseValidatorFn
+	responseErrorCheck func(status int, body []byte) error
+
+	propagateTraceContext bool
+	correlationIDHeader   string
+	generateCorrelationID bool
+
+	onResponse  func(resp *http.Response)
+	debug       *debugDumper
+	onError     func(ctx context.Context, method, url string, err error)
+	life        *lifecycle
+	compression bool
+	tokenSource TokenSource
 }
 
+// Do sends a request with payload encoded by the codec, JSON by default, and decodes the response into response.
+// A payload of type []byte, json.RawMessage or string is already encoded and is sent verbatim.
+// The payload is sent with any method, including GET and DELETE.
 func (c *Client) Do(ctx context.Context, method, path string, headers http.Header, payload, response any) error {
+	_, err := c.DoWithResponse(ctx, method, path, headers, payload, response)
+	return err
+}
+
+// DoWithResponse behaves like Do and additionally returns the response metadata.
+// The metadata is also returned along with an APIError when the server responded with an error status.
+func (c *Client) DoWithResponse(
+	ctx context.Context,
+	method, path string,
+	headers http.Header,
+	payload, response any,
+) (*Response, error) {
 	var reqBody io.Reader
 	if payload != nil {
-		jsonBytes, err := json.Marshal(payload)
+		jsonBytes, err := c.encodePayload(payload)
 		if err != nil {
-			return newInternalError("Do", fmt.Errorf("failed to marshal payload: %w", err))
+			return nil, c.failEarly(ctx, method, path, newInternalError("Do", fmt.Errorf("failed to marshal payload: %w", err)))
 		}
+		if c.maxRequestBytes > 0 && int64(len(jsonBytes)) > c.maxRequestBytes {
+			return nil, c.failEarly(ctx, method, path, newInternalError("Do", fmt.Errorf(
+				"%w: payload is %d bytes, limit is %d", ErrRequestTooLarge, len(jsonBytes), c.maxRequestBytes,
+			)))
+		}
+		if c.requestSchema != nil {
+			if err := c.requestSchema.Validate(jsonBytes); err != nil {
+				return nil, c.failEarly(ctx, method, path, newInternalError("Do", fmt.Errorf("%w: %w", ErrSchemaViolation, err)))
+			}
+		}
+		// A *bytes.Reader makes net/http set Content-Length and GetBody,
+		// so the body is never sent chunked and every retry resends it.
 		reqBody = bytes.NewReader(jsonBytes)
 	}
 
-	if headers == nil {
-		headers = http.Header{}
-	} else {
-		headers = headers.Clone()
-	}
-	if headers.Get("Accept") == "" {
-		headers.Set("Accept", "application/json")
+	headers = c.mergeHeaders(headers)
+	if !c.disableDefaultAccept && headers.Get("Accept") == "" {
+		headers.Set("Accept", c.defaultAccept())
 	}
 	if reqBody != nil && headers.Get("Content-Type") == "" {
-		headers.Set("Content-Type", "application/json")
+		headers.Set("Content-Type", c.codec.ContentType())
+	}
+
+	return c.doRAW(ctx, method, path, headers, reqBody, response)
+}
+
+// defaultAccept returns the Accept header set by Do when the request has none.
+func (c *Client) defaultAccept() string {
+	if c.accept != "" {
+		return c.accept
+	}
+	return c.codec.Accept()
+}
+
+// encodePayload marshals payload unless it is already encoded.
+func (c *Client) encodePayload(payload any) ([]byte, error) {
+	switch p := payload.(type) {
+	case json.RawMessage:
+		return p, nil
+	case []byte:
+		return p, nil
+	case string:
+		return []byte(p), nil
+	default:
+		return c.marshal(payload)
+	}
+}
+
+// Head performs a HEAD request and returns the response headers and status code.
+// No body is decoded. Error statuses such as 404 are returned as an APIError
+// along with the headers and status of the response.
+func (c *Client) Head(ctx context.Context, path string, headers http.Header) (http.Header, int, error) {
+	meta, err := c.doRAW(ctx, http.MethodHead, path, headers, nil, nil)
+	if meta == nil {
+		return nil, 0, err
 	}
 
-	return c.DoRAW(ctx, method, path, headers, reqBody, response)
+	return meta.Header, meta.StatusCode, err
 }
 
+// DoRAW sends payload as the request body without encoding it and decodes the
+// response into response. Payloads of known size, *bytes.Buffer, *bytes.Reader and
+// *strings.Reader, are sent with a Content-Length header. Other readers use chunked
+// transfer encoding unless they are buffered to be replayed by retries.
 func (c *Client) DoRAW(
 	ctx context.Context,
 	method, path string,
@@ -52,42 +429,497 @@ func (c *Client) DoRAW(
 	payload io.Reader,
 	response any,
 ) error {
+	_, err := c.doRAW(ctx, method, path, headers, payload, response)
+	return err
+}
+
+func (c *Client) doRAW(
+	ctx context.Context,
+	method, path string,
+	headers http.Header,
+	payload io.Reader,
+	response any,
+) (*Response, error) {
+	start := time.Now()
+	ctx, end, err := c.life.begin(ctx)
+	if err != nil {
+		return nil, c.failEarly(ctx, method, path, newInternalError("DoRAW", err))
+	}
+	defer end()
+
+	req, err := c.newRequest(ctx, method, path, headers, payload)
+	target := path
+	var meta *Response
+	if err == nil {
+		target = req.URL.String()
+		meta, err = c.execute(ctx, req, response)
+		err = closedError(ctx, err)
+	}
+	c.complete(ctx, start, method, path, target, meta, err)
+
+	return meta, err
+}
+
+// complete records the outcome of a call: it annotates the error with the
+// request, observes the metrics and reports the error to the OnError hook.
+func (c *Client) complete(
+	ctx context.Context,
+	start time.Time,
+	method, path, target string,
+	meta *Response,
+	err error,
+) {
+	annotateError(err, method, target)
+
+	statusCode := 0
+	if meta != nil {
+		statusCode = meta.StatusCode
+	}
+	c.metrics.ObserveRequest(method, path, statusCode, time.Since(start), err)
+
+	c.reportError(ctx, method, target, err)
+}
+
+// failEarly annotates and reports an error of a call that failed before a
+// request was built.
+func (c *Client) failEarly(ctx context.Context, method, path string, err *InternalError) error {
+	annotateError(err, method, path)
+	c.reportError(ctx, method, path, err)
+	return err
+}
+
+// reportError calls the OnError hook for a non-nil err.
+func (c *Client) reportError(ctx context.Context, method, target string, err error) {
+	if err != nil && c.onError != nil {
+		c.onError(ctx, method, target, err)
+	}
+}
+
+// DoRawPath behaves like DoRAW but sends the request to target exactly as given.
+// The URL does not go through path normalization, BasePath or reference resolution,
+// so an escaped path set in target.RawPath, such as a segment containing %2F, is kept
+// verbatim. When target has no host, the scheme, credentials and host of the base URL
+// are used while the path is left untouched.
+func (c *Client) DoRawPath(
+	ctx context.Context,
+	method string,
+	target *url.URL,
+	headers http.Header,
+	payload io.Reader,
+	response any,
+) error {
+	start := time.Now()
+	ctx, end, err := c.life.begin(ctx)
+	if err != nil {
+		return c.failEarly(ctx, method, target.String(), newInternalError("DoRawPath", err))
+	}
+	defer end()
+
+	req, err := c.newRequestURL(ctx, method, target, headers, payload)
+	resolved := target.String()
+	var meta *Response
+	if err == nil {
+		resolved = req.URL.String()
+		meta, err = c.execute(ctx, req, response)
+		err = closedError(ctx, err)
+	}
+	c.complete(ctx, start, method, target.String(), resolved, meta, err)
+
+	return err
+}
+
+// DoRequest sends a request built by the caller and decodes the response into
+// response like DoRAW. The request is sent as is, without the default headers,
+// but otherwise goes through the same handling: middlewares, request editors,
+// retries, metrics and the classification of errors. It runs with ctx instead
+// of the context of req, which is not modified.
+func (c *Client) DoRequest(ctx context.Context, req *http.Request, response any) error {
+	start := time.Now()
+	target := req.URL.String()
+	ctx, end, err := c.life.begin(ctx)
+	if err != nil {
+		return c.failEarly(ctx, req.Method, target, newInternalError("DoRequest", err))
+	}
+	defer end()
+
+	req = req.Clone(ctx)
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	meta, err := c.execute(ctx, req, response)
+	err = closedError(ctx, err)
+	c.complete(ctx, start, req.Method, target, target, meta, err)
+
+	return err
+}
+
+// execute sends req after applying the per-request decorations.
+func (c *Client) execute(ctx context.Context, req *http.Request, response any) (*Response, error) {
+	if err := c.prepare(ctx, req); err != nil {
+		return nil, err
+	}
+
+	if c.canReplay(req) {
+		if err := bufferBody(req, c.maxReplayBytes); err != nil {
+			return nil, newInternalError("DoRAW", err)
+		}
+	}
+
+	// GET requests with a body, as used by search APIs, are neither cached nor
+	// deduplicated since the URL does not identify them.
+	if req.Method == http.MethodGet && !hasBody(req) && !bypassesCache(ctx) {
+		if c.cache != nil {
+			return c.sendCached(req, response)
+		}
+		if c.flights != nil {
+			return c.sendDeduplicated(req, response)
+		}
+	}
+
+	return c.transmit(req, response)
+}
+
+// prepare applies the per-request decorations to req: the idempotency key,
+// the trace context, the correlation ID and the request editors.
+func (c *Client) prepare(ctx context.Context, req *http.Request) error {
+	if c.idempotencyKey && !isSafeMethod(req.Method) && req.Header.Get(idempotencyKeyHeader) == "" {
+		key, err := newUUID()
+		if err != nil {
+			return newInternalError("DoRAW", fmt.Errorf("failed to generate idempotency key: %w", err))
+		}
+		req.Header.Set(idempotencyKeyHeader, key)
+	}
+
+	if c.propagateTraceContext {
+		propagateTraceContext(ctx, req)
+	}
+
+	if c.correlationIDHeader != "" {
+		if err := propagateCorrelationID(ctx, req, c.correlationIDHeader, c.generateCorrelationID); err != nil {
+			return newInternalError("DoRAW", err)
+		}
+	}
+
+	for _, edit := range c.requestEditors {
+		if err := edit(ctx, req); err != nil {
+			return newInternalError("edit", err)
+		}
+	}
+
+	return nil
+}
+
+// hasBody reports whether req carries a request body.
+func hasBody(req *http.Request) bool {
+	return req.Body != nil && req.Body != http.NoBody
+}
+
+// transmit sends req through the reauthentication handling when it applies.
+func (c *Client) transmit(req *http.Request, response any) (*Response, error) {
+	if c.onUnauthorized != nil {
+		return c.sendWithReauth(req, response)
+	}
+
+	return c.dispatch(req, response)
+}
+
+// canReplay reports whether req may have to be sent more than once.
+func (c *Client) canReplay(req *http.Request) bool {
+	retry := c.retryPolicyFor(req.Context())
+	return (retry.maxAttempts > 1 && retry.canRetry(req)) ||
+		c.onUnauthorized != nil ||
+		(c.csrf != nil && !isSafeMethod(req.Method))
+}
+
+// dispatch sends req through the CSRF handling when it applies.
+func (c *Client) dispatch(req *http.Request, response any) (*Response, error) {
+	if c.csrf != nil && !isSafeMethod(req.Method) {
+		return c.sendWithCSRF(req, response)
+	}
+
+	return c.send(req, response)
+}
+
+// sendWithReauth dispatches req and, on a 401 response, refreshes credentials
+// with the OnUnauthorized callback and sends the request once more.
+func (c *Client) sendWithReauth(req *http.Request, response any) (*Response, error) {
+	meta, err := c.dispatch(req, response)
+	if apiErr, ok := AsAPIError(err); !ok || apiErr.StatusCode != http.StatusUnauthorized {
+		return meta, err
+	}
+
+	next, rewindErr := rewindRequest(req)
+	if rewindErr != nil {
+		return meta, err
+	}
+
+	if authErr := c.onUnauthorized(req.Context()); authErr != nil {
+		return meta, newInternalError("OnUnauthorized", authErr)
+	}
+
+	return c.dispatch(next, response)
+}
+
+// newRequest builds a request for path resolved against the base URL.
+func (c *Client) newRequest(
+	ctx context.Context,
+	method, reqPath string,
+	headers http.Header,
+	payload io.Reader,
+) (*http.Request, error) {
 	if method == "" {
-		return ErrEmptyMethod
+		return nil, ErrEmptyMethod
+	}
+
+	if c.normalizePaths {
+		reqPath = normalizePath(reqPath)
 	}
 
 	// Parse the path (this preserves query parameters)
-	pathURL, err := url.Parse(path)
+	pathURL, err := url.Parse(reqPath)
 	if err != nil {
-		return newInternalError("DoRAW", fmt.Errorf("failed to parse path: %w", err))
+		return nil, newInternalError("DoRAW", fmt.Errorf("failed to parse path: %w", err))
+	}
+
+	if c.basePath != "" && pathURL.Scheme == "" && pathURL.Host == "" {
+		// Join the escaped forms so that encoded separators such as %2F survive.
+		escaped := joinBasePath(c.basePath, pathURL.EscapedPath())
+		unescaped, err := url.PathUnescape(escaped)
+		if err != nil {
+			return nil, newInternalError("DoRAW", fmt.Errorf("failed to join base path: %w", err))
+		}
+		pathURL.Path = unescaped
+		pathURL.RawPath = escaped
 	}
 
 	// Resolve the path against the base URL to get a properly encoded full URL
-	fullURL := c.baseURL.ResolveReference(pathURL).String()
+	resolved := c.baseURL.ResolveReference(pathURL)
+	addDefaultQuery(resolved, c.query)
+	fullURL := resolved.String()
 
 	req, err := http.NewRequestWithContext(ctx, method, fullURL, payload)
 	if err != nil {
-		return newInternalError("DoRAW", fmt.Errorf("failed to create request: %w", err))
+		return nil, newInternalError("DoRAW", fmt.Errorf("failed to create request: %w", err))
+	}
+
+	c.prepareHeaders(ctx, req, headers)
+
+	return req, nil
+}
+
+// addDefaultQuery appends the parameters of defaults that u does not have yet.
+// The existing query string is kept as is.
+func addDefaultQuery(u *url.URL, defaults url.Values) {
+	if len(defaults) == 0 {
+		return
+	}
+
+	existing := u.Query()
+	missing := url.Values{}
+	for key, values := range defaults {
+		if !existing.Has(key) {
+			missing[key] = values
+		}
+	}
+	if len(missing) == 0 {
+		return
 	}
 
-	req.Header = headers
+	if u.RawQuery != "" {
+		u.RawQuery += "&"
+	}
+	u.RawQuery += missing.Encode()
+}
+
+// cloneValues returns a deep copy of values, or nil when it is empty.
+func cloneValues(values url.Values) url.Values {
+	if len(values) == 0 {
+		return nil
+	}
 
-	resp, err := c.client.Do(req)
+	clone := make(url.Values, len(values))
+	for key, items := range values {
+		clone[key] = append([]string(nil), items...)
+	}
+	return clone
+}
+
+// newRequestURL builds a request for target without resolving or normalizing its path.
+func (c *Client) newRequestURL(
+	ctx context.Context,
+	method string,
+	target *url.URL,
+	headers http.Header,
+	payload io.Reader,
+) (*http.Request, error) {
+	if method == "" {
+		return nil, ErrEmptyMethod
+	}
+
+	u := *target
+	if u.Host == "" {
+		u.Scheme = c.baseURL.Scheme
+		u.User = c.baseURL.User
+		u.Host = c.baseURL.Host
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL.String(), payload)
 	if err != nil {
-		return newInfrastructureError(fullURL, err)
+		return nil, newInternalError("DoRawPath", fmt.Errorf("failed to create request: %w", err))
+	}
+	req.URL = &u
+	req.Host = u.Host
+
+	c.prepareHeaders(ctx, req, headers)
+
+	return req, nil
+}
+
+// prepareHeaders sets the merged request headers on req.
+// A Host header overrides the host sent to the server, since net/http ignores
+// it in the header map, while the connection still goes to the URL host.
+func (c *Client) prepareHeaders(ctx context.Context, req *http.Request, headers http.Header) {
+	req.Header = c.mergeHeaders(headers)
+	applyContextHeaders(ctx, req.Header)
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+		req.Header.Del("Host")
+	}
+	if c.compression && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding())
+	}
+}
+
+// send performs req, retrying it according to the client's retry policy.
+func (c *Client) send(req *http.Request, response any) (*Response, error) {
+	ctx := req.Context()
+	retry := c.retryPolicyFor(ctx)
+
+	start := time.Now()
+
+	var delay time.Duration
+	for attempt := 1; ; attempt++ {
+		attemptStart := time.Now()
+		meta, err := c.roundTrip(req, response)
+		elapsed := time.Since(attemptStart)
+		if meta != nil {
+			meta.Attempts = attempt
+		}
+		if err == nil {
+			retry.budget.deposit()
+			return meta, withAttempts(err, attempt)
+		}
+		if !retry.shouldRetry(req, err) || attempt >= retry.maxAttempts {
+			return meta, withAttempts(err, attempt)
+		}
+
+		next, rewindErr := rewindRequest(req)
+		if rewindErr != nil {
+			return meta, withAttempts(err, attempt)
+		}
+
+		delay = retry.backoff.Next(attempt, delay)
+		if retry.exceedsBudget(start, delay) || ctx.Err() != nil || exceedsDeadline(ctx, delay, elapsed) {
+			return meta, withAttempts(err, attempt)
+		}
+		if !retry.budget.withdraw() {
+			return meta, withAttempts(err, attempt)
+		}
+		if sleepErr := sleepContext(ctx, delay); sleepErr != nil {
+			return meta, withAttempts(newInfrastructureError(req.URL.String(), sleepErr), attempt)
+		}
+
+		req = next
+	}
+}
+
+// roundTrip performs a single attempt of req and decodes the response.
+func (c *Client) roundTrip(req *http.Request, response any) (*Response, error) {
+	start := time.Now()
+
+	resp, err := c.hedgedExchange(req)
+	if resp == nil {
+		return nil, err
+	}
+
+	meta := newResponse(resp)
+	if err == nil {
+		err = c.validateResponse(resp)
+	}
+	if err == nil && c.responseErrorCheck != nil {
+		err = c.checkResponse(req, resp)
+	}
+	if err == nil {
+		if progress := downloadProgressFromContext(req.Context()); progress != nil {
+			trackDownload(resp, progress)
+		}
+		err = c.readResponse(resp, response)
 	}
-	defer func() {
+
+	if !c.disableBodyDrain {
 		_, _ = io.Copy(io.Discard, resp.Body)
-		resp.Body.Close()
-	}()
+	}
+	resp.Body.Close()
+	meta.Duration = time.Since(start)
 
-	if resp.StatusCode >= http.StatusBadRequest {
-		const maxErrBody = 1 << 20 // 1 MiB
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrBody))
+	return meta, err
+}
+
+// validateResponse runs the response validators against a successful response.
+func (c *Client) validateResponse(resp *http.Response) error {
+	for _, validate := range c.responseValidators {
+		if err := validate(resp); err != nil {
+			return newInternalError("validate", err)
+		}
+	}
+	return nil
+}
+
+// checkResponse buffers the body of a successful response and passes it to the
+// ResponseErrorCheck hook. An APIError returned by the hook is completed with
+// the details of the response, any other error is wrapped in an InternalError.
+func (c *Client) checkResponse(req *http.Request, resp *http.Response) error {
+	var body io.Reader = resp.Body
+	if c.maxResponseBytes > 0 {
+		body = &maxBytesReader{r: resp.Body, n: c.maxResponseBytes}
+	}
+
+	data, err := io.ReadAll(body)
+	resp.Body.Close()
+	if err != nil {
+		return newInternalError("check", fmt.Errorf("failed to read response: %w", err))
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	err = c.responseErrorCheck(resp.StatusCode, data)
+	if err == nil {
+		return nil
+	}
 
-		return c.formatError(resp.StatusCode, body, fullURL)
+	apiErr, ok := AsAPIError(err)
+	if !ok {
+		return newInternalError("check", err)
+	}
+	if apiErr.StatusCode == 0 {
+		apiErr.StatusCode = resp.StatusCode
 	}
+	if apiErr.URL == "" {
+		apiErr.URL = req.URL.String()
+	}
+	if apiErr.Body == nil {
+		apiErr.Body = data
+	}
+	if apiErr.ContentType == "" {
+		apiErr.ContentType = resp.Header.Get("Content-Type")
+	}
+	return err
+}
 
+// readResponse decodes the body of a successful response into response.
+func (c *Client) readResponse(resp *http.Response, response any) error {
 	if resp.StatusCode == http.StatusNoContent {
 		return nil
 	}
@@ -96,25 +928,243 @@ func (c *Client) DoRAW(
 		return nil
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return newInternalError("DoRAW", fmt.Errorf("failed to decode response: %w", err))
+	var body io.Reader = resp.Body
+	if c.maxResponseBytes > 0 {
+		body = &maxBytesReader{r: resp.Body, n: c.maxResponseBytes}
+	}
+
+	if err := c.decode(body, resp.Header.Get("Content-Type"), response); err != nil {
+		return newInternalError("DoRAW", err)
+	}
+
+	return nil
+}
+
+// decode reads body into response. Pointers to string and []byte receive the
+// raw body and an io.Writer has it copied in, any other target is decoded
+// according to contentType, as JSON by default.
+func (c *Client) decode(body io.Reader, contentType string, response any) error {
+	switch target := response.(type) {
+	case *string:
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return fmt.Errorf("failed to read response: %w", err)
+		}
+		*target = string(data)
+	case *[]byte:
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return fmt.Errorf("failed to read response: %w", err)
+		}
+		*target = data
+	case io.Writer:
+		if _, err := io.Copy(target, body); err != nil {
+			return fmt.Errorf("failed to write response: %w", err)
+		}
+	default:
+		decoder, err := c.responseDecoder(contentType)
+		if err != nil {
+			return err
+		}
+
+		captured := &limitedBuffer{buf: nil, limit: maxDecodeErrorBody}
+		r := skipBOM(io.TeeReader(body, captured))
+		switch {
+		case decoder != nil:
+			err = decoder.Decode(r, response)
+		case c.unwrapField != "":
+			err = c.decodeEnvelope(r, response)
+		default:
+			err = c.newDecoder(r).Decode(&response)
+		}
+		if err != nil {
+			// An empty body is treated like 204 No Content.
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			// Capture the rest of the body, up to the limit, for debugging.
+			_, _ = io.CopyN(captured, body, int64(captured.remaining()))
+			return &DecodeError{Err: err, Body: captured.buf}
+		}
 	}
 
 	return nil
 }
 
-func (c *Client) formatError(statusCode int, body []byte, reqURL string) error {
+// exchange sends req and converts error status codes into an APIError.
+// The response is returned whenever the server replied; the caller must close its body.
+func (c *Client) exchange(req *http.Request) (*http.Response, error) {
+	fullURL := req.URL.String()
+
+	if c.tokenSource != nil {
+		if err := c.authorize(req); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.signRequest != nil {
+		if err := c.sign(req); err != nil {
+			return nil, newInternalError("sign", err)
+		}
+	}
+
+	if c.debug != nil {
+		if err := c.debug.dumpRequest(req); err != nil {
+			return nil, newInternalError("debug", err)
+		}
+	}
+
+	if progress := uploadProgressFromContext(req.Context()); progress != nil {
+		trackUpload(req, progress)
+	}
+
+	if c.inFlight != nil {
+		c.inFlight.IncInFlight()
+	}
+	resp, err := c.doer().Do(req)
+	if err != nil {
+		if c.inFlight != nil {
+			c.inFlight.DecInFlight()
+		}
+		// A refused redirect is deterministic, so it is not a retryable transport failure.
+		if errors.Is(err, ErrTooManyRedirects) || errors.Is(err, ErrInsecureRedirect) {
+			return nil, newInternalError("redirect", err)
+		}
+		return nil, newInfrastructureError(fullURL, err)
+	}
+	if c.inFlight != nil {
+		resp.Body = &releaseOnClose{ReadCloser: resp.Body, once: sync.Once{}, release: c.inFlight.DecInFlight}
+	}
+
+	if c.onResponse != nil {
+		c.onResponse(resp)
+	}
+
+	if err := decompressBody(resp); err != nil {
+		resp.Body.Close()
+		return nil, newInternalError("decompress", err)
+	}
+
+	if c.debug != nil {
+		if err := c.debug.dumpResponse(resp); err != nil {
+			resp.Body.Close()
+			return nil, newInternalError("debug", err)
+		}
+	}
+
+	if !c.isSuccess(resp.StatusCode) {
+		const maxErrBody = 1 << 20 // 1 MiB
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrBody))
+
+		return resp, c.formatError(resp, body, fullURL)
+	}
+
+	return resp, nil
+}
+
+// JSONDecoder decodes JSON values from a stream. *json.Decoder implements it.
+type JSONDecoder interface {
+	Decode(v any) error
+}
+
+// newDecoder returns a JSON decoder for r configured according to the client settings.
+func (c *Client) newDecoder(r io.Reader) JSONDecoder {
+	if c.newJSONDecoder != nil {
+		return c.newJSONDecoder(r)
+	}
+
+	decoder := json.NewDecoder(r)
+	if c.disallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if c.useNumber {
+		decoder.UseNumber()
+	}
+	return decoder
+}
+
+// mergeHeaders returns a copy of headers with the client's default headers
+// added for keys that are not already set.
+func (c *Client) mergeHeaders(headers http.Header) http.Header {
+	if headers == nil {
+		headers = http.Header{}
+	} else {
+		headers = headers.Clone()
+	}
+	for key, values := range c.headers {
+		if _, ok := headers[key]; !ok {
+			headers[key] = append([]string(nil), values...)
+		}
+	}
+	return headers
+}
+
+func defaultIsSuccess(statusCode int) bool {
+	return statusCode < http.StatusBadRequest
+}
+
+func (c *Client) formatError(resp *http.Response, body []byte, reqURL string) error {
 	return &APIError{
-		StatusCode: statusCode,
-		URL:        reqURL,
-		Body:       body,
+		StatusCode:  resp.StatusCode,
+		URL:         reqURL,
+		Body:        body,
+		ContentType: resp.Header.Get("Content-Type"),
+		Attempts:    0,
+		errorField:  c.unwrapErrorField,
+	}
+}
+
+// Clone returns a copy of the client with opts applied.
+// The base URL is reused without re-parsing. The clone shares the same
+// *http.Client and CSRF token as the original unless overridden by an option.
+func (c *Client) Clone(opts ...Option) *Client {
+	clone := *c
+	clone.headers = c.headers.Clone()
+	for _, opt := range opts {
+		opt(&clone)
 	}
+	return &clone
 }
 
-func NewClient(config Config) (*Client, error) {
+// BaseURL returns a copy of the parsed base URL of the client.
+func (c *Client) BaseURL() *url.URL {
+	baseURL := *c.baseURL
+	return &baseURL
+}
+
+func NewClient(config Config, opts ...Option) (*Client, error) {
+	if err := resolveUnixSocket(&config); err != nil {
+		return nil, err
+	}
+	var transport *http.Transport
 	if config.Client == nil {
 		config.Client = http.DefaultClient
+		if transport = newTransport(config); transport != nil {
+			config.Client = &http.Client{Transport: transport}
+		}
+	}
+	if config.Metrics == nil {
+		config.Metrics = NoopMetrics{}
+	}
+	if config.MaxReplayBytes <= 0 {
+		config.MaxReplayBytes = defaultMaxReplayBytes
 	}
+	if config.IsSuccess == nil {
+		config.IsSuccess = defaultIsSuccess
+	}
+	if config.Codec == nil {
+		config.Codec = JSONCodec{}
+	}
+	if config.Marshal == nil {
+		config.Marshal = config.Codec.Marshal
+	}
+	if config.Unmarshal == nil {
+		config.Unmarshal = json.Unmarshal
+	}
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = defaultCacheTTL
+	}
+	config.Client = withRedirectPolicy(config.Client, config.MaxRedirects, config.AllowInsecureRedirect)
 
 	// Parse the base URL
 	baseURL, err := url.Parse(config.BaseURL)
@@ -126,8 +1176,156 @@ func NewClient(config Config) (*Client, error) {
 		return nil, fmt.Errorf("%w: base URL must be absolute (got %q)", ErrInvalidConfig, config.BaseURL)
 	}
 
-	return &Client{
-		client:  config.Client,
-		baseURL: baseURL,
-	}, nil
+	c := &Client{
+		client:    config.Client,
+		transport: transport,
+
+		maxRedirects:          config.MaxRedirects,
+		allowInsecureRedirect: config.AllowInsecureRedirect,
+		baseURL:               baseURL,
+		basePath:              normalizeBasePath(config.BasePath),
+		headers:               config.Headers.Clone(),
+		query:                 cloneValues(config.DefaultQuery),
+		retry:                 newRetryPolicy(config.Retry),
+		csrf:                  newCSRFState(config.CSRF),
+		metrics:               config.Metrics,
+		inFlight:              nil,
+
+		middlewares: config.Middlewares,
+		signRequest: config.SignRequest,
+
+		onUnauthorized: config.OnUnauthorized,
+		maxReplayBytes: config.MaxReplayBytes,
+
+		isSuccess: config.IsSuccess,
+
+		idempotencyKey:        config.IdempotencyKey,
+		maxResponseBytes:      config.MaxResponseBytes,
+		maxRequestBytes:       config.MaxRequestBytes,
+		requestSchema:         config.RequestSchema,
+		disallowUnknownFields: config.DisallowUnknownFields,
+		useNumber:             config.UseNumber,
+		disableDefaultAccept:  config.DisableDefaultAccept,
+		disableBodyDrain:      config.DisableBodyDrain,
+		accept:                acceptHeader(config.Accept),
+		decoders:              nil,
+		normalizePaths:        config.NormalizePaths,
+		unwrapField:           config.UnwrapField,
+		unwrapErrorField:      config.UnwrapErrorField,
+
+		codec:          config.Codec,
+		marshal:        config.Marshal,
+		unmarshal:      config.Unmarshal,
+		newJSONDecoder: config.NewDecoder,
+
+		hedging: config.Hedging,
+
+		cache:    config.Cache,
+		cacheTTL: config.CacheTTL,
+		flights:  nil,
+
+		requestEditors:     config.RequestEditors,
+		responseValidators: config.ResponseValidators,
+		responseErrorCheck: config.ResponseErrorCheck,
+
+		propagateTraceContext: config.PropagateTraceContext,
+		correlationIDHeader:   config.CorrelationIDHeader,
+		generateCorrelationID: config.GenerateCorrelationID,
+
+		onResponse:  config.OnResponse,
+		debug:       nil,
+		onError:     config.OnError,
+		life:        newLifecycle(),
+		compression: config.Compression,
+		tokenSource: config.TokenSource,
+	}
+	if config.Singleflight {
+		c.flights = newFlightGroup()
+	}
+	if config.Debug {
+		out := config.DebugOutput
+		if out == nil {
+			out = os.Stderr
+		}
+		c.debug = newDebugDumper(out)
+	}
+	if len(config.Decoders) > 0 {
+		c.decoders = make(map[string]ResponseDecoder, len(config.Decoders))
+		for mediaType, decoder := range config.Decoders {
+			c.decoders[MediaType(mediaType)] = decoder
+		}
+	}
+	if inFlight, ok := config.Metrics.(InFlightMetrics); ok {
+		c.inFlight = inFlight
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// normalizeBasePath returns basePath with a single leading slash and no trailing
+// slash, or an empty string when it has no segments.
+func normalizeBasePath(basePath string) string {
+	basePath = strings.Trim(basePath, "/")
+	if basePath == "" {
+		return ""
+	}
+	return "/" + basePath
+}
+
+// joinBasePath prefixes reqPath with basePath, keeping a trailing slash of reqPath.
+func joinBasePath(basePath, reqPath string) string {
+	reqPath = strings.TrimLeft(reqPath, "/")
+	if reqPath == "" {
+		return basePath
+	}
+	return basePath + "/" + reqPath
+}
+
+// normalizePath collapses duplicate slashes and resolves dot segments in the path
+// portion of a relative reference. Leading and trailing slashes are preserved and
+// `..` segments cannot climb above the root of the reference.
+// References with a scheme are returned unchanged.
+func normalizePath(ref string) string {
+	p, rest := ref, ""
+	if i := strings.IndexAny(ref, "?#"); i >= 0 {
+		p, rest = ref[:i], ref[i:]
+	}
+	if p == "" || strings.Contains(p, "://") {
+		return ref
+	}
+
+	cleaned := path.Clean("/" + p)
+	if !strings.HasPrefix(p, "/") {
+		cleaned = strings.TrimPrefix(cleaned, "/")
+	}
+	if strings.HasSuffix(p, "/") && !strings.HasSuffix(cleaned, "/") {
+		cleaned += "/"
+	}
+
+	return cleaned + rest
+}
+
+// maxBytesReader reads from r and fails with ErrResponseTooLarge once more than n bytes are read.
+type maxBytesReader struct {
+	r io.Reader
+	n int64
+}
+
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	if m.n < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	if int64(len(p)) > m.n+1 {
+		p = p[:m.n+1]
+	}
+
+	n, err := m.r.Read(p)
+	m.n -= int64(n)
+	if m.n < 0 {
+		return n + int(m.n), ErrResponseTooLarge
+	}
+	return n, err
 }
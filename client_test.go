@@ -1,12 +1,23 @@
 package restkit_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"io"
 	"math"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	rest "github.com/capcom6/go-restkit"
 )
@@ -37,6 +48,14 @@ func setupTestServer(t *testing.T) *httptest.Server {
 			w.WriteHeader(http.StatusInternalServerError)
 			_, _ = w.Write([]byte("internal server error"))
 			return
+		case "/empty":
+			w.WriteHeader(http.StatusOK)
+			return
+		case "/bom":
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("\xef\xbb\xbf" + `{"id": "123", "state": "Pending"}`))
+			return
 		case "/corrupt":
 			w.WriteHeader(http.StatusOK)
 			w.Header().Add("Content-Type", "application/json")
@@ -189,6 +208,36 @@ func TestClient_Do(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Empty response",
+			fields: fields{
+				config: rest.Config{
+					BaseURL: httpServer.URL,
+				},
+			},
+			args: args{
+				ctx:      context.Background(),
+				method:   http.MethodGet,
+				path:     "/empty",
+				response: new(map[string]any),
+			},
+			wantErr: false,
+		},
+		{
+			name: "Response with BOM",
+			fields: fields{
+				config: rest.Config{
+					BaseURL: httpServer.URL,
+				},
+			},
+			args: args{
+				ctx:      context.Background(),
+				method:   http.MethodGet,
+				path:     "/bom",
+				response: new(map[string]any),
+			},
+			wantErr: false,
+		},
 		{
 			name: "Corrupt response",
 			fields: fields{
@@ -286,3 +335,1094 @@ func TestAPIErrorBodyParsing(t *testing.T) {
 		t.Errorf("Unexpected error message: %s", customErr.Message)
 	}
 }
+
+func TestClient_Clone(t *testing.T) {
+	var gotHeader string
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Variant")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer httpServer.Close()
+
+	base, err := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		Headers: http.Header{"X-Variant": []string{"base"}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	clone := base.Clone(rest.WithHeaders(http.Header{"X-Variant": []string{"clone"}}))
+
+	if err := clone.Do(context.Background(), http.MethodGet, "/", nil, nil, nil); err != nil {
+		t.Fatalf("clone.Do() error = %v", err)
+	}
+	if gotHeader != "clone" {
+		t.Errorf("Expected clone header, got %q", gotHeader)
+	}
+
+	if err := base.Do(context.Background(), http.MethodGet, "/", nil, nil, nil); err != nil {
+		t.Fatalf("base.Do() error = %v", err)
+	}
+	if gotHeader != "base" {
+		t.Errorf("Expected base header to be unaffected by clone, got %q", gotHeader)
+	}
+
+	headers := http.Header{"X-Variant": []string{"request"}}
+	if err := clone.Do(context.Background(), http.MethodGet, "/", headers, nil, nil); err != nil {
+		t.Fatalf("clone.Do() error = %v", err)
+	}
+	if gotHeader != "request" {
+		t.Errorf("Expected per-request header to take precedence, got %q", gotHeader)
+	}
+}
+
+func TestClient_NormalizePaths(t *testing.T) {
+	var gotPath string
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.RequestURI()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer httpServer.Close()
+
+	tests := []struct {
+		name    string
+		baseURL string
+		path    string
+		want    string
+	}{
+		{name: "Double slash", baseURL: httpServer.URL, path: "//users", want: "/users"},
+		{name: "Inner slashes", baseURL: httpServer.URL, path: "/api//users///1", want: "/api/users/1"},
+		{name: "Trailing slash", baseURL: httpServer.URL, path: "/users//", want: "/users/"},
+		{name: "Dot segments", baseURL: httpServer.URL, path: "/a/./b/../c", want: "/a/c"},
+		{name: "Query preserved", baseURL: httpServer.URL, path: "//users?next=a//b", want: "/users?next=a//b"},
+		{name: "URL in query", baseURL: httpServer.URL, path: "//users?next=https://example.com/x", want: "/users?next=https://example.com/x"},
+		{name: "Absolute traversal", baseURL: httpServer.URL + "/v1/", path: "/../../etc/passwd", want: "/etc/passwd"},
+		{name: "Relative traversal", baseURL: httpServer.URL + "/v1/", path: "../../etc/passwd", want: "/v1/etc/passwd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := rest.NewClient(rest.Config{BaseURL: tt.baseURL, NormalizePaths: true})
+			if err := client.Do(context.Background(), http.MethodGet, tt.path, nil, nil, nil); err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			if gotPath != tt.want {
+				t.Errorf("Expected path %q, got %q", tt.want, gotPath)
+			}
+		})
+	}
+}
+
+func TestClient_DisableDefaultAccept(t *testing.T) {
+	var accept []string
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Values("Accept")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+	if err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if len(accept) != 1 || accept[0] != "application/json" {
+		t.Errorf("Expected default Accept header, got %v", accept)
+	}
+
+	client, _ = rest.NewClient(rest.Config{BaseURL: httpServer.URL, DisableDefaultAccept: true})
+	if err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if len(accept) != 0 {
+		t.Errorf("Expected no Accept header, got %v", accept)
+	}
+}
+
+func TestClient_MaxResponseBytes(t *testing.T) {
+	httpServer := setupTestServer(t)
+	defer httpServer.Close()
+
+	// The default response `{"id": "123", "state": "Pending"}` is 33 bytes long.
+	tests := []struct {
+		name    string
+		limit   int64
+		wantErr bool
+	}{
+		{name: "Unlimited", limit: 0, wantErr: false},
+		{name: "Exact limit", limit: 33, wantErr: false},
+		{name: "Exceeded", limit: 16, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL, MaxResponseBytes: tt.limit})
+
+			err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, new(map[string]any))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && (!rest.IsInternalError(err) || !errors.Is(err, rest.ErrResponseTooLarge)) {
+				t.Errorf("Expected internal ErrResponseTooLarge error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestClient_DisallowUnknownFields(t *testing.T) {
+	httpServer := setupTestServer(t)
+	defer httpServer.Close()
+
+	type partial struct {
+		ID string `json:"id"`
+	}
+
+	lenient, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+	if err := lenient.Do(context.Background(), http.MethodGet, "/", nil, nil, new(partial)); err != nil {
+		t.Errorf("Expected lenient decoding to succeed, got %v", err)
+	}
+
+	strict, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL, DisallowUnknownFields: true})
+	err := strict.Do(context.Background(), http.MethodGet, "/", nil, nil, new(partial))
+	if !rest.IsInternalError(err) || !strings.Contains(err.Error(), `"state"`) {
+		t.Errorf("Expected internal error naming the unknown field, got %v", err)
+	}
+}
+
+func TestClient_UseNumber(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"id": 9007199254740993}`))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL, UseNumber: true})
+
+	var resp map[string]any
+	if err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, &resp); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	id, ok := resp["id"].(json.Number)
+	if !ok {
+		t.Fatalf("Expected json.Number, got %T", resp["id"])
+	}
+	if id.String() != "9007199254740993" {
+		t.Errorf("Expected precise id, got %s", id)
+	}
+}
+
+func TestClient_RawResponses(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("plain text, not JSON"))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	var text string
+	if err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, &text); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if text != "plain text, not JSON" {
+		t.Errorf("Unexpected string response: %q", text)
+	}
+
+	var raw []byte
+	if err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, &raw); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if string(raw) != "plain text, not JSON" {
+		t.Errorf("Unexpected []byte response: %q", raw)
+	}
+}
+
+func TestClient_OnUnauthorized(t *testing.T) {
+	var attempts atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "payload" {
+			t.Errorf("Expected body to be resent, got %q", body)
+		}
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer httpServer.Close()
+
+	var token atomic.Value
+	auth := func(next rest.Doer) rest.Doer {
+		return rest.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("Authorization", "Bearer "+token.Load().(string))
+			return next.Do(req)
+		})
+	}
+
+	t.Run("Refreshed", func(t *testing.T) {
+		attempts.Store(0)
+		token.Store("expired")
+		client, _ := rest.NewClient(rest.Config{
+			BaseURL:     httpServer.URL,
+			Middlewares: []rest.Middleware{auth},
+			OnUnauthorized: func(context.Context) error {
+				token.Store("fresh")
+				return nil
+			},
+		})
+
+		body := io.MultiReader(strings.NewReader("payload"))
+		if err := client.DoRAW(context.Background(), http.MethodPost, "/", nil, body, nil); err != nil {
+			t.Fatalf("DoRAW() error = %v", err)
+		}
+		if attempts.Load() != 2 {
+			t.Errorf("Expected 2 attempts, got %d", attempts.Load())
+		}
+	})
+
+	t.Run("Still unauthorized", func(t *testing.T) {
+		attempts.Store(0)
+		token.Store("expired")
+		client, _ := rest.NewClient(rest.Config{
+			BaseURL:        httpServer.URL,
+			Middlewares:    []rest.Middleware{auth},
+			OnUnauthorized: func(context.Context) error { return nil },
+		})
+
+		err := client.DoRAW(context.Background(), http.MethodPost, "/", nil, strings.NewReader("payload"), nil)
+		if apiErr, ok := rest.AsAPIError(err); !ok || apiErr.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected 401 API error, got %v", err)
+		}
+		if attempts.Load() != 2 {
+			t.Errorf("Expected exactly one retry, got %d attempts", attempts.Load())
+		}
+	})
+}
+
+func TestClient_Head(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("Expected HEAD, got %s", r.Method)
+		}
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", "1024")
+		w.Header().Set("ETag", `"v1"`)
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	header, status, err := client.Head(context.Background(), "/files/1", nil)
+	if err != nil {
+		t.Fatalf("Head() error = %v", err)
+	}
+	if status != http.StatusOK || header.Get("Content-Length") != "1024" || header.Get("ETag") != `"v1"` {
+		t.Errorf("Unexpected response: %d %v", status, header)
+	}
+
+	_, status, err = client.Head(context.Background(), "/missing", nil)
+	if apiErr, ok := rest.AsAPIError(err); !ok || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 API error, got %v", err)
+	}
+	if status != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", status)
+	}
+}
+
+func TestClient_BOM(t *testing.T) {
+	httpServer := setupTestServer(t)
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL, Cache: rest.NewLRUCache(1)})
+
+	for range 2 {
+		var resp struct {
+			ID string `json:"id"`
+		}
+		if err := client.Do(context.Background(), http.MethodGet, "/bom", nil, nil, &resp); err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		if resp.ID != "123" {
+			t.Errorf("Expected id 123, got %q", resp.ID)
+		}
+	}
+}
+
+func TestMediaType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        string
+	}{
+		{contentType: "application/json", want: "application/json"},
+		{contentType: "application/json; charset=utf-8", want: "application/json"},
+		{contentType: "Application/JSON;charset=UTF-8", want: "application/json"},
+		{contentType: "application/problem+json; charset=utf-8", want: "application/problem+json"},
+		{contentType: "text/plain; broken=", want: "text/plain"},
+		{contentType: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := rest.MediaType(tt.contentType); got != tt.want {
+			t.Errorf("MediaType(%q) = %q, want %q", tt.contentType, got, tt.want)
+		}
+	}
+}
+
+func TestClient_MaxRequestBytes(t *testing.T) {
+	var requests int
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.ContentLength != 13 {
+			t.Errorf("Expected Content-Length 13, got %d", r.ContentLength)
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer httpServer.Close()
+
+	// The payload `{"foo":"bar"}` is 13 bytes long.
+	tests := []struct {
+		name    string
+		limit   int64
+		wantErr bool
+	}{
+		{name: "Unlimited", limit: 0, wantErr: false},
+		{name: "Exact limit", limit: 13, wantErr: false},
+		{name: "Exceeded", limit: 12, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = 0
+			client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL, MaxRequestBytes: tt.limit})
+
+			err := client.Do(context.Background(), http.MethodPost, "/", nil, map[string]string{"foo": "bar"}, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !rest.IsInternalError(err) || !errors.Is(err, rest.ErrRequestTooLarge) {
+					t.Errorf("Expected internal ErrRequestTooLarge error, got %v", err)
+				}
+				if requests != 0 {
+					t.Errorf("Expected oversized request not to be sent, got %d requests", requests)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_CustomJSON(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	defer httpServer.Close()
+
+	var marshaled, decoded int
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		Marshal: func(v any) ([]byte, error) {
+			marshaled++
+			return json.Marshal(v)
+		},
+		NewDecoder: func(r io.Reader) rest.JSONDecoder {
+			decoded++
+			return json.NewDecoder(r)
+		},
+	})
+
+	var resp map[string]string
+	if err := client.Do(context.Background(), http.MethodPost, "/", nil, map[string]string{"foo": "bar"}, &resp); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	if resp["foo"] != "bar" {
+		t.Errorf("Expected echoed payload, got %v", resp)
+	}
+	if marshaled != 1 || decoded != 1 {
+		t.Errorf("Expected custom marshaler and decoder to be used once, got %d and %d", marshaled, decoded)
+	}
+}
+
+func TestClient_PathJoin(t *testing.T) {
+	var gotURL string
+	httpClient := &http.Client{Transport: rest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		gotURL = req.URL.String()
+		return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Header: http.Header{}, Request: req}, nil
+	})}
+
+	tests := []struct {
+		name     string
+		baseURL  string
+		basePath string
+		path     string
+		want     string
+	}{
+		// RFC 3986 resolution against BaseURL.
+		{name: "Base without slash", baseURL: "https://api.example.com/v1", path: "users", want: "https://api.example.com/users"},
+		{name: "Base with slash", baseURL: "https://api.example.com/v1/", path: "users", want: "https://api.example.com/v1/users"},
+		{name: "Leading slash", baseURL: "https://api.example.com/v1/", path: "/users", want: "https://api.example.com/users"},
+		{name: "Absolute URL", baseURL: "https://api.example.com/v1/", path: "https://other.example.com/x", want: "https://other.example.com/x"},
+
+		// BasePath is always prefixed.
+		{name: "BasePath relative", baseURL: "https://api.example.com", basePath: "/v1", path: "users", want: "https://api.example.com/v1/users"},
+		{name: "BasePath leading slash", baseURL: "https://api.example.com", basePath: "v1/", path: "/users", want: "https://api.example.com/v1/users"},
+		{name: "BasePath trailing slash", baseURL: "https://api.example.com", basePath: "/v1", path: "users/", want: "https://api.example.com/v1/users/"},
+		{name: "BasePath query", baseURL: "https://api.example.com", basePath: "/v1", path: "/users?page=2", want: "https://api.example.com/v1/users?page=2"},
+		{name: "BasePath empty path", baseURL: "https://api.example.com", basePath: "/v1", path: "", want: "https://api.example.com/v1"},
+		{name: "BasePath nested", baseURL: "https://api.example.com/ignored", basePath: "/api/v2", path: "users/1", want: "https://api.example.com/api/v2/users/1"},
+		{name: "BasePath absolute URL", baseURL: "https://api.example.com", basePath: "/v1", path: "https://other.example.com/x", want: "https://other.example.com/x"},
+		{name: "BasePath escaped slash", baseURL: "https://api.example.com", basePath: "/v1", path: "keys/ns%2Fkey", want: "https://api.example.com/v1/keys/ns%2Fkey"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := rest.NewClient(rest.Config{Client: httpClient, BaseURL: tt.baseURL, BasePath: tt.basePath})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			if err := client.Do(context.Background(), http.MethodGet, tt.path, nil, nil, nil); err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			if gotURL != tt.want {
+				t.Errorf("Expected URL %q, got %q", tt.want, gotURL)
+			}
+		})
+	}
+}
+
+func TestClient_DoRawPath(t *testing.T) {
+	var gotURI string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURI = r.RequestURI
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	client, err := rest.NewClient(rest.Config{BaseURL: server.URL + "/api/", BasePath: "/v1", NormalizePaths: true})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		target *url.URL
+		want   string
+	}{
+		{
+			name:   "Encoded slash and space",
+			target: &url.URL{Path: "/keys/ns/key one", RawPath: "/keys/ns%2Fkey%20one"},
+			want:   "/keys/ns%2Fkey%20one",
+		},
+		{
+			name:   "Query",
+			target: &url.URL{Path: "/keys/a/b", RawPath: "/keys/a%2Fb", RawQuery: "q=x%2Fy"},
+			want:   "/keys/a%2Fb?q=x%2Fy",
+		},
+		{
+			name:   "Dot segments kept",
+			target: &url.URL{Path: "/a//b/../c"},
+			want:   "/a//b/../c",
+		},
+		{
+			name:   "Absolute URL",
+			target: mustParseURL(t, server.URL+"/other/x%2Fy"),
+			want:   "/other/x%2Fy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp struct {
+				OK bool `json:"ok"`
+			}
+			if err := client.DoRawPath(context.Background(), http.MethodGet, tt.target, nil, nil, &resp); err != nil {
+				t.Fatalf("DoRawPath() error = %v", err)
+			}
+			if gotURI != tt.want {
+				t.Errorf("Expected request URI %q, got %q", tt.want, gotURI)
+			}
+			if !resp.OK {
+				t.Error("Expected response to be decoded")
+			}
+		})
+	}
+}
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	return u
+}
+
+func TestClient_HostHeader(t *testing.T) {
+	var gotHost, gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.Host
+		gotHeader = r.Header.Get("Host")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client, err := rest.NewClient(rest.Config{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	headers := http.Header{}
+	headers.Set("Host", "tenant.example.com")
+	if err := client.Do(context.Background(), http.MethodGet, "/", headers, nil, nil); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if gotHost != "tenant.example.com" {
+		t.Errorf("Expected host %q, got %q", "tenant.example.com", gotHost)
+	}
+	if gotHeader != "" {
+		t.Errorf("Expected no duplicate Host header, got %q", gotHeader)
+	}
+	if headers.Get("Host") != "tenant.example.com" {
+		t.Error("Expected caller headers to be left unchanged")
+	}
+}
+
+func TestClient_DecodeIntoWriter(t *testing.T) {
+	const body = `{"not":"decoded"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client, err := rest.NewClient(rest.Config{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	t.Run("Buffer", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := client.Do(context.Background(), http.MethodGet, "/data", nil, nil, &buf); err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		if buf.String() != body {
+			t.Errorf("Expected %q, got %q", body, buf.String())
+		}
+	})
+
+	t.Run("File", func(t *testing.T) {
+		file, err := os.Create(filepath.Join(t.TempDir(), "data.json"))
+		if err != nil {
+			t.Fatalf("os.Create() error = %v", err)
+		}
+		defer file.Close()
+
+		if err := client.Do(context.Background(), http.MethodGet, "/data", nil, nil, file); err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		data, err := os.ReadFile(file.Name())
+		if err != nil {
+			t.Fatalf("os.ReadFile() error = %v", err)
+		}
+		if string(data) != body {
+			t.Errorf("Expected %q, got %q", body, string(data))
+		}
+	})
+
+	t.Run("Error status", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := client.Do(context.Background(), http.MethodGet, "/missing", nil, nil, &buf)
+		if !rest.IsAPIError(err) {
+			t.Fatalf("Expected APIError, got %v", err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("Expected error body not to be written, got %q", buf.String())
+		}
+	})
+}
+
+func TestClient_RawPayload(t *testing.T) {
+	var gotBody, gotContentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		gotContentType = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client, err := rest.NewClient(rest.Config{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		payload any
+		want    string
+	}{
+		{name: "RawMessage", payload: json.RawMessage(`{"id": 1,  "tags": ["a"]}`), want: `{"id": 1,  "tags": ["a"]}`},
+		{name: "Bytes", payload: []byte(`{"id":2}`), want: `{"id":2}`},
+		{name: "String", payload: `{"id":3}`, want: `{"id":3}`},
+		{name: "Struct", payload: struct {
+			ID int `json:"id"`
+		}{ID: 4}, want: `{"id":4}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := client.Do(context.Background(), http.MethodPost, "/", nil, tt.payload, nil); err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			if gotBody != tt.want {
+				t.Errorf("Expected body %q, got %q", tt.want, gotBody)
+			}
+			if gotContentType != "application/json" {
+				t.Errorf("Expected JSON content type, got %q", gotContentType)
+			}
+		})
+	}
+}
+
+func TestClient_ContentLength(t *testing.T) {
+	type request struct {
+		contentLength    int64
+		transferEncoding []string
+		body             string
+	}
+
+	var (
+		mu       sync.Mutex
+		requests []request
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, request{r.ContentLength, r.TransferEncoding, string(body)})
+		first := len(requests) == 1
+		mu.Unlock()
+		if first {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client, err := rest.NewClient(rest.Config{
+		BaseURL: server.URL,
+		Retry:   rest.RetryConfig{MaxAttempts: 2, Backoff: constantBackoff(time.Millisecond)},
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	payload := map[string]string{"name": strings.Repeat("x", 64<<10)}
+	if err := client.Do(context.Background(), http.MethodPut, "/", nil, payload, nil); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	want, _ := json.Marshal(payload)
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", len(requests))
+	}
+	for i, r := range requests {
+		if r.contentLength != int64(len(want)) {
+			t.Errorf("Attempt %d: expected Content-Length %d, got %d", i+1, len(want), r.contentLength)
+		}
+		if len(r.transferEncoding) != 0 {
+			t.Errorf("Attempt %d: expected no transfer encoding, got %v", i+1, r.transferEncoding)
+		}
+		if r.body != string(want) {
+			t.Errorf("Attempt %d: expected body to be sent in full", i+1)
+		}
+	}
+}
+
+func TestClient_OnError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	type report struct {
+		method string
+		url    string
+		err    error
+	}
+
+	tests := []struct {
+		name    string
+		baseURL string
+		path    string
+		payload any
+		wantURL string
+		check   func(error) bool
+	}{
+		{name: "API error", baseURL: server.URL, path: "/fail", wantURL: server.URL + "/fail", check: rest.IsAPIError},
+		{name: "Infrastructure error", baseURL: "http://localhost:1", path: "/x", wantURL: "http://localhost:1/x", check: rest.IsInfrastructureError},
+		{name: "Internal error", baseURL: server.URL, path: "/ok", payload: make(chan int), wantURL: "/ok", check: rest.IsInternalError},
+		{name: "Success", baseURL: server.URL, path: "/ok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reports []report
+			client, err := rest.NewClient(rest.Config{
+				BaseURL: tt.baseURL,
+				Retry:   rest.RetryConfig{MaxAttempts: 3, Backoff: constantBackoff(time.Millisecond)},
+				OnError: func(_ context.Context, method, url string, err error) {
+					reports = append(reports, report{method: method, url: url, err: err})
+				},
+			})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			err = client.Do(context.Background(), http.MethodPut, tt.path, nil, tt.payload, nil)
+			if tt.check == nil {
+				if err != nil || len(reports) != 0 {
+					t.Errorf("Expected no error reports, got %v and %d reports", err, len(reports))
+				}
+				return
+			}
+
+			if len(reports) != 1 {
+				t.Fatalf("Expected exactly one report, got %d", len(reports))
+			}
+			got := reports[0]
+			if got.method != http.MethodPut || got.url != tt.wantURL {
+				t.Errorf("Expected PUT %s, got %s %s", tt.wantURL, got.method, got.url)
+			}
+			if !errors.Is(got.err, err) || !tt.check(got.err) {
+				t.Errorf("Expected the returned error to be reported, got %v", got.err)
+			}
+		})
+	}
+}
+
+func TestClient_BodyOnAnyMethod(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var query struct {
+			Term string `json:"term"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"method":      r.Method,
+			"term":        query.Term,
+			"contentType": r.Header.Get("Content-Type"),
+		})
+	}))
+	defer server.Close()
+
+	client, err := rest.NewClient(rest.Config{
+		BaseURL:      server.URL,
+		Cache:        rest.NewLRUCache(10),
+		Singleflight: true,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	tests := []struct {
+		method string
+		term   string
+	}{
+		{method: http.MethodGet, term: "first"},
+		{method: http.MethodGet, term: "second"},
+		{method: http.MethodDelete, term: "third"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.term, func(t *testing.T) {
+			var got map[string]string
+			err := client.Do(context.Background(), tt.method, "/_search", nil, map[string]string{"term": tt.term}, &got)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			if got["method"] != tt.method || got["term"] != tt.term {
+				t.Errorf("Expected %s with term %q, got %v", tt.method, tt.term, got)
+			}
+			if got["contentType"] != "application/json" {
+				t.Errorf("Expected JSON content type, got %q", got["contentType"])
+			}
+		})
+	}
+}
+
+func TestClient_DefaultQuery(t *testing.T) {
+	var gotQuery string
+	httpClient := &http.Client{Transport: rest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		gotQuery = req.URL.RawQuery
+		return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Header: http.Header{}, Request: req}, nil
+	})}
+
+	defaults := url.Values{"api-version": {"2024-01-01"}}
+	client, err := rest.NewClient(rest.Config{Client: httpClient, BaseURL: "https://api.example.com", DefaultQuery: defaults})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defaults.Set("api-version", "changed")
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "Added", path: "/items", want: "api-version=2024-01-01"},
+		{name: "Other params kept", path: "/items?page=2&q=a+b", want: "page=2&q=a+b&api-version=2024-01-01"},
+		{name: "Path takes precedence", path: "/items?api-version=preview", want: "api-version=preview"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := client.Do(context.Background(), http.MethodGet, tt.path, nil, nil, nil); err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			if gotQuery != tt.want {
+				t.Errorf("Expected query %q, got %q", tt.want, gotQuery)
+			}
+		})
+	}
+
+	if err := client.DoRawPath(context.Background(), http.MethodGet, mustParseURL(t, "/raw"), nil, nil, nil); err != nil {
+		t.Fatalf("DoRawPath() error = %v", err)
+	}
+	if gotQuery != "" {
+		t.Errorf("Expected DoRawPath to keep the query empty, got %q", gotQuery)
+	}
+}
+
+var errNotStreamed = errors.New("body was not streamed")
+
+func TestClient_ChunkedUpload(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		retry  rest.RetryConfig
+	}{
+		{name: "No retries", method: http.MethodPut, retry: rest.RetryConfig{}},
+		{name: "Method not retried", method: http.MethodPost, retry: rest.RetryConfig{MaxAttempts: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received := make(chan struct{})
+			var (
+				contentLength    int64
+				transferEncoding []string
+				body             []byte
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contentLength = r.ContentLength
+				transferEncoding = r.TransferEncoding
+
+				first := make([]byte, len("first,"))
+				if _, err := io.ReadFull(r.Body, first); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				close(received)
+				remaining, _ := io.ReadAll(r.Body)
+				body = append(first, remaining...)
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			client, err := rest.NewClient(rest.Config{BaseURL: server.URL, Retry: tt.retry})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			// The second chunk is written only once the server got the first one,
+			// so buffering the body before sending it would block.
+			pr, pw := io.Pipe()
+			go func() {
+				_, _ = pw.Write([]byte("first,"))
+				select {
+				case <-received:
+					_, _ = pw.Write([]byte("second"))
+					_ = pw.Close()
+				case <-time.After(5 * time.Second):
+					_ = pw.CloseWithError(errNotStreamed)
+				}
+			}()
+
+			if err := client.DoRAW(context.Background(), tt.method, "/", nil, pr, nil); err != nil {
+				t.Fatalf("DoRAW() error = %v", err)
+			}
+			if contentLength != -1 {
+				t.Errorf("Expected unknown Content-Length, got %d", contentLength)
+			}
+			if len(transferEncoding) != 1 || transferEncoding[0] != "chunked" {
+				t.Errorf("Expected chunked transfer encoding, got %v", transferEncoding)
+			}
+			if string(body) != "first,second" {
+				t.Errorf("Expected body %q, got %q", "first,second", body)
+			}
+		})
+	}
+}
+
+func TestClient_BaseURL(t *testing.T) {
+	client, err := rest.NewClient(rest.Config{BaseURL: "https://user@api.example.com/v1/"})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	baseURL := client.BaseURL()
+	if baseURL.String() != "https://user@api.example.com/v1/" {
+		t.Errorf("BaseURL() = %q", baseURL)
+	}
+
+	baseURL.Host = "evil.example.com"
+	if got := client.BaseURL().Host; got != "api.example.com" {
+		t.Errorf("Expected BaseURL() to return a copy, host changed to %q", got)
+	}
+}
+
+func TestClient_DoRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("X-Signature") != "signed" || r.Header.Get("X-Default") != "" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":"unexpected headers"}`))
+			return
+		}
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	var middlewareCalls int
+	metrics := &recordingMetrics{}
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: "https://ignored.example.com",
+		Headers: http.Header{"X-Default": {"1"}},
+		Metrics: metrics,
+		Middlewares: []rest.Middleware{func(next rest.Doer) rest.Doer {
+			return rest.DoerFunc(func(req *http.Request) (*http.Response, error) {
+				middlewareCalls++
+				return next.Do(req)
+			})
+		}},
+	})
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/echo", strings.NewReader(`{"id":7}`))
+	req.Header.Set("X-Signature", "signed")
+
+	var resp struct {
+		ID int `json:"id"`
+	}
+	if err := client.DoRequest(context.Background(), req, &resp); err != nil {
+		t.Fatalf("DoRequest() error = %v", err)
+	}
+	if resp.ID != 7 {
+		t.Errorf("Expected the response to be decoded, got %+v", resp)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/missing", nil)
+	req.Header.Set("X-Signature", "signed")
+	if err := client.DoRequest(context.Background(), req, &resp); !rest.IsNotFound(err) {
+		t.Errorf("Expected a 404 API error, got %v", err)
+	}
+
+	if middlewareCalls != 2 {
+		t.Errorf("Expected the middleware to run for both requests, got %d", middlewareCalls)
+	}
+	if len(metrics.observations) != 2 || metrics.observations[1].statusCode != http.StatusNotFound {
+		t.Errorf("Expected both requests to be observed, got %+v", metrics.observations)
+	}
+}
+
+func TestClient_DoRequestNilHeader(t *testing.T) {
+	var gotKey, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey, gotAuth = r.Header.Get("Idempotency-Key"), r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		IdempotencyKey: true,
+		TokenSource: rest.TokenSourceFunc(func() (*rest.Token, error) {
+			return &rest.Token{AccessToken: "token"}, nil
+		}),
+	})
+
+	req := &http.Request{Method: http.MethodPost, URL: mustParseURL(t, server.URL+"/items")}
+	if err := client.DoRequest(context.Background(), req, nil); err != nil {
+		t.Fatalf("DoRequest() error = %v", err)
+	}
+	if gotKey == "" || gotAuth != "Bearer token" {
+		t.Errorf("Expected idempotency key and Authorization headers, got %q and %q", gotKey, gotAuth)
+	}
+	if req.Header != nil {
+		t.Error("Expected the caller's request to be left unchanged")
+	}
+}
+
+func TestClient_DisableBodyDrain(t *testing.T) {
+	tests := []struct {
+		name            string
+		disable         bool
+		wantConnections int32
+	}{
+		{name: "Drained", disable: false, wantConnections: 1},
+		{name: "Not drained", disable: true, wantConnections: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write(bytes.Repeat([]byte("x"), 1<<20))
+			}))
+			var connections atomic.Int32
+			server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					connections.Add(1)
+				}
+			}
+			server.Start()
+			defer server.Close()
+
+			client, _ := rest.NewClient(rest.Config{
+				BaseURL:          server.URL,
+				Client:           &http.Client{Transport: &http.Transport{}},
+				DisableBodyDrain: tt.disable,
+			})
+			for range 2 {
+				if err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil); err != nil {
+					t.Fatalf("Do() error = %v", err)
+				}
+			}
+
+			if got := connections.Load(); got != tt.wantConnections {
+				t.Errorf("Expected %d connections, got %d", tt.wantConnections, got)
+			}
+		})
+	}
+}
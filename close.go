@@ -0,0 +1,116 @@
+package restkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// lifecycle tracks the calls in flight so that the client can be closed.
+// It is shared by a client and its clones.
+type lifecycle struct {
+	mu     sync.Mutex
+	closed bool
+	calls  sync.WaitGroup
+
+	ctx    context.Context //nolint:containedctx // canceled by Close to abort calls
+	cancel context.CancelFunc
+}
+
+func newLifecycle() *lifecycle {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &lifecycle{mu: sync.Mutex{}, closed: false, calls: sync.WaitGroup{}, ctx: ctx, cancel: cancel}
+}
+
+// begin registers a call and returns its context, which is canceled when the
+// client is closed, and the function ending the call. It fails with ErrClosed
+// once the client is closed.
+func (l *lifecycle) begin(ctx context.Context) (context.Context, func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return ctx, func() {}, ErrClosed
+	}
+	l.calls.Add(1)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(l.ctx, func() { cancel(ErrClosed) })
+
+	return ctx, func() {
+		stop()
+		cancel(nil)
+		l.calls.Done()
+	}, nil
+}
+
+// shutdown stops new calls from starting.
+func (l *lifecycle) shutdown() {
+	l.mu.Lock()
+	l.closed = true
+	l.mu.Unlock()
+}
+
+// wait blocks until every call in flight has ended or ctx is done.
+func (l *lifecycle) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		l.calls.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck // transparent wrapper
+	}
+}
+
+// abort cancels the calls in flight and waits for them to end.
+func (l *lifecycle) abort() {
+	l.cancel()
+	l.calls.Wait()
+}
+
+// closedError marks err of a call whose context ctx was canceled by closing
+// the client so that it matches ErrClosed.
+func closedError(ctx context.Context, err error) error {
+	if err == nil || errors.Is(err, ErrClosed) || !errors.Is(context.Cause(ctx), ErrClosed) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrClosed, err)
+}
+
+// Close cancels the calls in flight and waits for them to return. Later calls
+// fail with ErrClosed. A client and its clones are closed together.
+// Idle connections are only closed when NewClient built a dedicated transport:
+// the shared `http.DefaultTransport` and the transport of a supplied
+// Config.Client may serve other clients and are left untouched.
+func (c *Client) Close() error {
+	c.life.shutdown()
+	c.life.abort()
+	c.closeIdleConnections()
+
+	return nil
+}
+
+// CloseGraceful stops new calls, failing them with ErrClosed, and waits for the
+// calls in flight to finish. When ctx is done first, the remaining calls are
+// canceled as by Close and the context error is returned.
+func (c *Client) CloseGraceful(ctx context.Context) error {
+	c.life.shutdown()
+	err := c.life.wait(ctx)
+	c.life.abort()
+	c.closeIdleConnections()
+
+	return err
+}
+
+// closeIdleConnections closes the idle connections of the transport owned by c.
+func (c *Client) closeIdleConnections() {
+	if c.transport != nil {
+		c.transport.CloseIdleConnections()
+	}
+}
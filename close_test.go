@@ -0,0 +1,208 @@
+package restkit_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+func setupBlockingServer(t *testing.T, arrived chan<- struct{}, release <-chan struct{}) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		select {
+		case arrived <- struct{}{}:
+		default:
+		}
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	return server, &requests
+}
+
+func TestClient_Close(t *testing.T) {
+	arrived := make(chan struct{}, 1)
+	server, requests := setupBlockingServer(t, arrived, nil)
+	defer server.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: server.URL})
+
+	result := make(chan error, 1)
+	go func() {
+		result <- client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	}()
+	<-arrived
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := <-result; !errors.Is(err, rest.ErrClosed) || !rest.IsInfrastructureError(err) {
+		t.Errorf("Expected the call in flight to be canceled with ErrClosed, got %v", err)
+	}
+
+	err := client.Clone().Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	if !errors.Is(err, rest.ErrClosed) || !rest.IsInternalError(err) {
+		t.Errorf("Expected ErrClosed after Close, got %v", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected 1 request, got %d", got)
+	}
+}
+
+func TestClient_CloseGraceful(t *testing.T) {
+	arrived := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server, _ := setupBlockingServer(t, arrived, release)
+	defer server.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: server.URL})
+
+	result := make(chan error, 1)
+	go func() {
+		result <- client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	}()
+	<-arrived
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- client.CloseGraceful(context.Background())
+	}()
+
+	// Probe until CloseGraceful stops new calls; probes sent before are abandoned.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		err := client.Do(ctx, http.MethodGet, "/", nil, nil, nil)
+		cancel()
+		if errors.Is(err, rest.ErrClosed) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected new calls to fail with ErrClosed")
+		}
+	}
+
+	select {
+	case err := <-closed:
+		t.Fatalf("Expected CloseGraceful to wait for the call in flight, got %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-result; err != nil {
+		t.Errorf("Expected the call in flight to finish, got %v", err)
+	}
+	if err := <-closed; err != nil {
+		t.Errorf("CloseGraceful() error = %v", err)
+	}
+}
+
+func TestClient_CloseGracefulTimeout(t *testing.T) {
+	arrived := make(chan struct{}, 1)
+	server, _ := setupBlockingServer(t, arrived, nil)
+	defer server.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: server.URL})
+
+	result := make(chan error, 1)
+	go func() {
+		result <- client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	}()
+	<-arrived
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := client.CloseGraceful(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected CloseGraceful() to time out, got %v", err)
+	}
+	if err := <-result; !errors.Is(err, rest.ErrClosed) {
+		t.Errorf("Expected the remaining call to be canceled with ErrClosed, got %v", err)
+	}
+}
+
+func TestClient_CloseIdleConnections(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     func(transport *http.Transport) rest.Config
+		opts       []rest.Option
+		wantClosed bool
+	}{
+		{
+			name: "Dedicated transport",
+			config: func(*http.Transport) rest.Config {
+				return rest.Config{MaxIdleConnsPerHost: 4}
+			},
+			wantClosed: true,
+		},
+		{
+			name: "Supplied client",
+			config: func(transport *http.Transport) rest.Config {
+				return rest.Config{Client: &http.Client{Transport: transport}}
+			},
+			wantClosed: false,
+		},
+		{
+			name: "Transport option",
+			config: func(*http.Transport) rest.Config {
+				return rest.Config{}
+			},
+			opts:       []rest.Option{rest.WithInsecureSkipVerify(true)},
+			wantClosed: true,
+		},
+		{
+			name: "Dedicated transport with transport option",
+			config: func(*http.Transport) rest.Config {
+				return rest.Config{MaxIdleConnsPerHost: 4}
+			},
+			opts:       []rest.Option{rest.WithInsecureSkipVerify(true)},
+			wantClosed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var closed atomic.Int32
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+				if state == http.StateClosed {
+					closed.Add(1)
+				}
+			}
+			server.Start()
+			defer server.Close()
+
+			transport := &http.Transport{}
+			defer transport.CloseIdleConnections()
+
+			config := tt.config(transport)
+			config.BaseURL = server.URL
+			client, _ := rest.NewClient(config, tt.opts...)
+			if err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil); err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			_ = client.Close()
+
+			deadline := time.Now().Add(200 * time.Millisecond)
+			for closed.Load() == 0 && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+			if got := closed.Load() > 0; got != tt.wantClosed {
+				t.Errorf("Expected idle connection closed = %v, got %v", tt.wantClosed, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package restkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ResponseDecoder decodes successful response bodies of a media type.
+type ResponseDecoder interface {
+	Decode(r io.Reader, v any) error
+}
+
+// ResponseDecoderFunc adapts an ordinary function to the ResponseDecoder interface.
+type ResponseDecoderFunc func(r io.Reader, v any) error
+
+// Decode calls f(r, v).
+func (f ResponseDecoderFunc) Decode(r io.Reader, v any) error {
+	return f(r, v)
+}
+
+// XMLContentType is the media type of payloads encoded by XMLCodec.
+const XMLContentType = "application/xml"
+
+// Codec encodes payloads and decodes responses of a media type.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+
+	// ContentType is the default Content-Type of encoded payloads.
+	ContentType() string
+	// Accept is the default Accept header of requests sent by Do.
+	Accept() string
+}
+
+// JSONCodec encodes and decodes JSON with encoding/json. It is the default codec.
+type JSONCodec struct{}
+
+// Marshal encodes v with json.Marshal.
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v) //nolint:wrapcheck // transparent wrapper
+}
+
+// Unmarshal decodes data into v with json.Unmarshal.
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v) //nolint:wrapcheck // transparent wrapper
+}
+
+// ContentType returns `application/json`.
+func (JSONCodec) ContentType() string { return "application/json" }
+
+// Accept returns `application/json`.
+func (JSONCodec) Accept() string { return "application/json" }
+
+// XMLCodec encodes and decodes XML with encoding/xml.
+type XMLCodec struct{}
+
+// Marshal encodes v with xml.Marshal.
+func (XMLCodec) Marshal(v any) ([]byte, error) {
+	return xml.Marshal(v) //nolint:wrapcheck // transparent wrapper
+}
+
+// Unmarshal decodes data into v with xml.Unmarshal.
+func (XMLCodec) Unmarshal(data []byte, v any) error {
+	return xml.Unmarshal(data, v) //nolint:wrapcheck // transparent wrapper
+}
+
+// ContentType returns XMLContentType.
+func (XMLCodec) ContentType() string { return XMLContentType }
+
+// Accept returns XMLContentType.
+func (XMLCodec) Accept() string { return XMLContentType }
+
+// acceptHeader builds a quality-valued Accept header from media types listed in
+// order of preference, e.g. `application/json, text/csv;q=0.9`. Quality values
+// decrease by 0.1 down to 0.1.
+func acceptHeader(mediaTypes []string) string {
+	parts := make([]string, 0, len(mediaTypes))
+	for i, mediaType := range mediaTypes {
+		if i == 0 {
+			parts = append(parts, mediaType)
+			continue
+		}
+		tenths := max(10-i, 1) //nolint:mnd // quality in tenths
+		parts = append(parts, mediaType+";q=0."+strconv.Itoa(tenths))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// isJSONMediaType reports whether mediaType is `application/json` or a `+json` type.
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// responseDecoder returns the decoder registered for contentType, falling back
+// to CSVDecoder for CSV and to the codec for its media type, or for responses
+// without a Content-Type when the codec is not JSON. It returns nil for JSON,
+// which is decoded by the built-in decoder, and ErrUnsupportedMediaType for
+// other media types when content negotiation is configured.
+func (c *Client) responseDecoder(contentType string) (ResponseDecoder, error) {
+	mediaType := MediaType(contentType)
+	if decoder, ok := c.decoders[mediaType]; ok {
+		return decoder, nil
+	}
+	if mediaType == CSVContentType {
+		return CSVDecoder{Comma: 0}, nil
+	}
+	if codecType := MediaType(c.codec.ContentType()); !isJSONMediaType(codecType) &&
+		(mediaType == "" || mediaType == codecType) {
+		return ResponseDecoderFunc(c.decodeCodec), nil
+	}
+	if mediaType == "" || isJSONMediaType(mediaType) || (c.accept == "" && len(c.decoders) == 0) {
+		return nil, nil //nolint:nilnil // nil selects the JSON decoder
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, mediaType)
+}
+
+// decodeCodec reads r and unmarshals it into v with the codec of the client.
+func (c *Client) decodeCodec(r io.Reader, v any) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	return c.codec.Unmarshal(data, v) //nolint:wrapcheck // transparent wrapper
+}
+
+// decodeEnvelope decodes the UnwrapField of the JSON object read from r into v.
+func (c *Client) decodeEnvelope(r io.Reader, v any) error {
+	var envelope map[string]json.RawMessage
+	if err := c.newDecoder(r).Decode(&envelope); err != nil {
+		return err //nolint:wrapcheck // transparent wrapper
+	}
+
+	field, ok := envelope[c.unwrapField]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrEnvelopeField, c.unwrapField)
+	}
+
+	return c.newDecoder(bytes.NewReader(field)).Decode(&v) //nolint:wrapcheck // transparent wrapper
+}
@@ -0,0 +1,237 @@
+package restkit_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+var errUnsupportedTarget = errors.New("unsupported target")
+
+func setupNegotiationServer(t *testing.T, gotAccept *string) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*gotAccept = r.Header.Get("Accept")
+		switch r.URL.Path {
+		case "/csv":
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			_, _ = w.Write([]byte("a\nb\n"))
+		case "/html":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html></html>"))
+		case "/plain":
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte(`["plain"]`))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`["json"]`))
+		}
+	}))
+}
+
+func TestClient_ContentNegotiation(t *testing.T) {
+	var gotAccept string
+	server := setupNegotiationServer(t, &gotAccept)
+	defer server.Close()
+
+	lines := rest.ResponseDecoderFunc(func(r io.Reader, v any) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		target, ok := v.(*[]string)
+		if !ok {
+			return errUnsupportedTarget
+		}
+		*target = strings.Fields(string(data))
+		return nil
+	})
+
+	client, err := rest.NewClient(rest.Config{
+		BaseURL:  server.URL,
+		Accept:   []string{"application/json", "text/csv", "text/plain"},
+		Decoders: map[string]rest.ResponseDecoder{"Text/CSV": lines},
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    []string
+		wantErr error
+	}{
+		{name: "JSON", path: "/json", want: []string{"json"}},
+		{name: "CSV decoder", path: "/csv", want: []string{"a", "b"}},
+		{name: "No decoder", path: "/html", wantErr: rest.ErrUnsupportedMediaType},
+		{name: "Unregistered accepted type", path: "/plain", wantErr: rest.ErrUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := client.Do(context.Background(), http.MethodGet, tt.path, nil, nil, &got)
+			if want := "application/json, text/csv;q=0.9, text/plain;q=0.8"; gotAccept != want {
+				t.Errorf("Expected Accept %q, got %q", want, gotAccept)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) || !rest.IsInternalError(err) {
+					t.Errorf("Expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClient_NoNegotiation(t *testing.T) {
+	var gotAccept string
+	server := setupNegotiationServer(t, &gotAccept)
+	defer server.Close()
+
+	client, err := rest.NewClient(rest.Config{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	// Without negotiation every response is decoded as JSON.
+	var got []string
+	if err := client.Do(context.Background(), http.MethodGet, "/plain", nil, nil, &got); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if len(got) != 1 || got[0] != "plain" {
+		t.Errorf("Expected [plain], got %v", got)
+	}
+	if gotAccept != "application/json" {
+		t.Errorf("Expected Accept %q, got %q", "application/json", gotAccept)
+	}
+}
+
+func TestClient_Codec(t *testing.T) {
+	type item struct {
+		Name string `json:"name" xml:"name"`
+	}
+
+	var gotAccept, gotContentType, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotAccept, gotContentType, gotBody = r.Header.Get("Accept"), r.Header.Get("Content-Type"), string(body)
+		if strings.HasPrefix(gotAccept, rest.XMLContentType) {
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+			_, _ = w.Write([]byte(`<item><name>pong</name></item>`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name":"pong"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name            string
+		codec           rest.Codec
+		wantAccept      string
+		wantContentType string
+		wantBody        string
+	}{
+		{name: "Default", codec: nil, wantAccept: "application/json", wantContentType: "application/json", wantBody: `{"name":"ping"}`},
+		{name: "JSON", codec: rest.JSONCodec{}, wantAccept: "application/json", wantContentType: "application/json", wantBody: `{"name":"ping"}`},
+		{name: "XML", codec: rest.XMLCodec{}, wantAccept: rest.XMLContentType, wantContentType: rest.XMLContentType, wantBody: `<item><name>ping</name></item>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := rest.NewClient(rest.Config{BaseURL: server.URL, Codec: tt.codec})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			var resp item
+			if err := client.Do(context.Background(), http.MethodPost, "/", nil, item{Name: "ping"}, &resp); err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			if gotAccept != tt.wantAccept {
+				t.Errorf("Expected Accept %q, got %q", tt.wantAccept, gotAccept)
+			}
+			if gotContentType != tt.wantContentType {
+				t.Errorf("Expected Content-Type %q, got %q", tt.wantContentType, gotContentType)
+			}
+			if gotBody != tt.wantBody {
+				t.Errorf("Expected body %q, got %q", tt.wantBody, gotBody)
+			}
+			if resp.Name != "pong" {
+				t.Errorf("Expected the response to be decoded, got %+v", resp)
+			}
+		})
+	}
+}
+
+func TestClient_UnwrapField(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/bare":
+			_, _ = w.Write([]byte(`{"id":1}`))
+		case "/fail":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"code":"invalid"}}`))
+		case "/gateway":
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`{"message":"upstream down"}`))
+		default:
+			_, _ = w.Write([]byte(`{"data":{"id":1},"meta":{"total":1}}`))
+		}
+	}))
+	defer server.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: server.URL, UnwrapField: "data", UnwrapErrorField: "error"})
+
+	var resp struct {
+		ID int `json:"id"`
+	}
+	if err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, &resp); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if resp.ID != 1 {
+		t.Errorf("Expected the data field to be decoded, got %+v", resp)
+	}
+
+	err := client.Do(context.Background(), http.MethodGet, "/bare", nil, nil, &resp)
+	if _, ok := rest.AsDecodeError(err); !ok || !errors.Is(err, rest.ErrEnvelopeField) {
+		t.Errorf("Expected a DecodeError for a missing data field, got %v", err)
+	}
+
+	var details struct {
+		Code string `json:"code"`
+	}
+	err = client.Do(context.Background(), http.MethodGet, "/fail", nil, nil, &resp)
+	apiErr, ok := rest.AsAPIError(err)
+	if !ok {
+		t.Fatalf("Expected an API error, got %v", err)
+	}
+	if parseErr := apiErr.ParseError(&details); parseErr != nil || details.Code != "invalid" {
+		t.Errorf("Expected the error field to be parsed, got %+v, %v", details, parseErr)
+	}
+
+	err = client.Do(context.Background(), http.MethodGet, "/gateway", nil, nil, &resp)
+	apiErr, ok = rest.AsAPIError(err)
+	if !ok {
+		t.Fatalf("Expected an API error, got %v", err)
+	}
+	if parseErr := apiErr.ParseError(&details); !errors.Is(parseErr, rest.ErrEnvelopeField) {
+		t.Errorf("Expected ErrEnvelopeField for a missing error field, got %v", parseErr)
+	}
+}
@@ -0,0 +1,113 @@
+package restkit
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// decompressor wraps a compressed body in a reader of the decompressed content.
+type decompressor func(r io.Reader) (io.ReadCloser, error)
+
+// decompressors returns the supported content encodings in order of preference.
+func decompressors() ([]string, map[string]decompressor) {
+	names := []string{"gzip", "deflate"}
+	decoders := map[string]decompressor{
+		"gzip":    func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+		"deflate": newDeflateReader,
+	}
+
+	if br := brotliDecompressor(); br != nil {
+		names = append([]string{"br"}, names...)
+		decoders["br"] = br
+	}
+
+	return names, decoders
+}
+
+// acceptEncoding returns the `Accept-Encoding` value listing the supported encodings.
+func acceptEncoding() string {
+	names, _ := decompressors()
+	return strings.Join(names, ", ")
+}
+
+// decompressBody replaces the body of resp with its decoded content according to
+// the `Content-Encoding` header. Bodies already decoded by the transport are left alone,
+// as are responses that carry no body, and an empty body is treated as an empty payload.
+func decompressBody(resp *http.Response) error {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if encoding == "" || encoding == "identity" || resp.Uncompressed || !hasResponseBody(resp) {
+		return nil
+	}
+
+	_, decoders := decompressors()
+	decode, ok := decoders[encoding]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnsupportedEncoding, encoding)
+	}
+
+	reader, err := decode(resp.Body)
+	if errors.Is(err, io.EOF) {
+		reader, err = io.NopCloser(strings.NewReader("")), nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode %s body: %w", encoding, err)
+	}
+
+	resp.Body = &decompressedBody{ReadCloser: reader, raw: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+
+	return nil
+}
+
+// hasResponseBody reports whether resp may carry a body at all (RFC 9110, Section 6.4.1).
+func hasResponseBody(resp *http.Response) bool {
+	if resp.Request != nil && resp.Request.Method == http.MethodHead {
+		return false
+	}
+
+	return resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotModified
+}
+
+// decompressedBody closes both the decoder and the underlying compressed body.
+type decompressedBody struct {
+	io.ReadCloser
+
+	raw io.Closer
+}
+
+func (b *decompressedBody) Close() error {
+	_ = b.ReadCloser.Close()
+	return b.raw.Close() //nolint:wrapcheck // transparent wrapper
+}
+
+// newDeflateReader reads a "deflate" body, which should be zlib-wrapped but is
+// sent as raw DEFLATE data by some servers.
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(r)
+
+	header, err := buffered.Peek(2) //nolint:mnd // zlib header size
+	if len(header) == 0 && errors.Is(err, io.EOF) {
+		return nil, io.EOF
+	}
+	if err == nil && isZlibHeader(header) {
+		return zlib.NewReader(buffered) //nolint:wrapcheck // wrapped by the caller
+	}
+
+	return flate.NewReader(buffered), nil
+}
+
+// isZlibHeader reports whether header starts a zlib stream (RFC 1950).
+func isZlibHeader(header []byte) bool {
+	const deflateMethod = 8
+	return header[0]&0x0f == deflateMethod && (uint16(header[0])<<8|uint16(header[1]))%31 == 0
+}
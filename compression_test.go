@@ -0,0 +1,151 @@
+package restkit_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+// compress encodes data with the given content encoding.
+func compress(t testing.TB, encoding string, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	default:
+		if encoder := brotliEncoder(&buf, encoding); encoder != nil {
+			w = encoder
+			break
+		}
+		return data
+	}
+	_, _ = w.Write(data)
+	_ = w.Close()
+
+	return buf.Bytes()
+}
+
+// setupCompressionServer returns a server that encodes its responses with the
+// encoding named by the `encoding` query parameter.
+func setupCompressionServer(t testing.TB) (*httptest.Server, *string) {
+	t.Helper()
+
+	var acceptEncoding string
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+
+		encoding := r.URL.Query().Get("encoding")
+		body := []byte(`{"message": "hello"}`)
+		if r.URL.Query().Has("fail") {
+			w.Header().Set("Content-Encoding", strings.TrimPrefix(encoding, "raw-"))
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write(compress(t, encoding, []byte(`{"error": "bad"}`)))
+			return
+		}
+
+		w.Header().Set("Content-Encoding", strings.TrimPrefix(encoding, "raw-"))
+		_, _ = w.Write(compress(t, encoding, body))
+	}))
+
+	return httpServer, &acceptEncoding
+}
+
+func TestDecompression(t *testing.T) {
+	httpServer, acceptEncoding := setupCompressionServer(t)
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL, Compression: true})
+
+	encodings := []string{"gzip", "deflate", "raw-deflate"}
+	if brotliSupported {
+		encodings = append(encodings, "br")
+	}
+
+	for _, encoding := range encodings {
+		t.Run(encoding, func(t *testing.T) {
+			var resp struct {
+				Message string `json:"message"`
+			}
+			if err := client.Do(context.Background(), http.MethodGet, "/?encoding="+encoding, nil, nil, &resp); err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			if resp.Message != "hello" {
+				t.Errorf("Expected decompressed message, got %q", resp.Message)
+			}
+
+			err := client.Do(context.Background(), http.MethodGet, "/?fail&encoding="+encoding, nil, nil, nil)
+			if apiErr, ok := rest.AsAPIError(err); !ok || string(apiErr.Body) != `{"error": "bad"}` {
+				t.Errorf("Expected API error with decompressed body, got %v", err)
+			}
+		})
+	}
+
+	if !strings.Contains(*acceptEncoding, "gzip") || !strings.Contains(*acceptEncoding, "deflate") {
+		t.Errorf("Expected supported encodings to be advertised, got %q", *acceptEncoding)
+	}
+}
+
+func TestDecompression_UnsupportedEncoding(t *testing.T) {
+	httpServer, _ := setupCompressionServer(t)
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	err := client.Do(context.Background(), http.MethodGet, "/?encoding=zstd", nil, nil, new(map[string]any))
+	if !rest.IsInternalError(err) || !errors.Is(err, rest.ErrUnsupportedEncoding) {
+		t.Errorf("Expected internal ErrUnsupportedEncoding error, got %v", err)
+	}
+}
+
+func TestDecompression_EmptyBody(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", r.URL.Query().Get("encoding"))
+		switch r.URL.Query().Get("status") {
+		case "204":
+			w.WriteHeader(http.StatusNoContent)
+		case "304":
+			w.WriteHeader(http.StatusNotModified)
+		}
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL, Compression: true})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{name: "head", method: http.MethodHead, path: "/?encoding=gzip"},
+		{name: "no content", method: http.MethodGet, path: "/?encoding=gzip&status=204"},
+		{name: "not modified", method: http.MethodGet, path: "/?encoding=gzip&status=304"},
+		{name: "empty gzip", method: http.MethodGet, path: "/?encoding=gzip"},
+		{name: "empty deflate", method: http.MethodGet, path: "/?encoding=deflate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := client.Do(context.Background(), tt.method, tt.path, nil, nil, nil)
+			if rest.IsInternalError(err) {
+				t.Errorf("Do() error = %v, want no decoding failure", err)
+			}
+		})
+	}
+}
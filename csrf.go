@@ -0,0 +1,188 @@
+package restkit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// CSRFSource defines where the CSRF token is read from.
+type CSRFSource int
+
+const (
+	CSRFSourceBody   CSRFSource = iota // Top-level string field of the JSON response body
+	CSRFSourceHeader                   // Response header
+	CSRFSourceCookie                   // Response cookie
+)
+
+const defaultCSRFHeader = "X-Csrf-Token"
+
+// CSRFConfig configures fetching a CSRF token and attaching it to unsafe requests
+// (all methods except GET, HEAD, OPTIONS and TRACE).
+type CSRFConfig struct {
+	Path   string     // Path of the endpoint issuing the token, requested with GET
+	Source CSRFSource // Where the token is read from in the token endpoint response
+	Name   string     // Name of the body field, header or cookie holding the token
+	Header string     // Optional request header carrying the token, defaults to `X-Csrf-Token`
+
+	// IsFailure reports whether an API error signals a rejected CSRF token.
+	// Optional, defaults to any 403 response.
+	IsFailure func(apiErr *APIError) bool
+}
+
+type csrfState struct {
+	config CSRFConfig
+
+	mu    sync.Mutex
+	token string
+}
+
+func newCSRFState(config *CSRFConfig) *csrfState {
+	if config == nil {
+		return nil
+	}
+
+	cfg := *config
+	if cfg.Header == "" {
+		cfg.Header = defaultCSRFHeader
+	}
+
+	return &csrfState{config: cfg, mu: sync.Mutex{}, token: ""}
+}
+
+func (s *csrfState) isFailure(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	if !ok {
+		return false
+	}
+	if s.config.IsFailure != nil {
+		return s.config.IsFailure(apiErr)
+	}
+	return apiErr.StatusCode == http.StatusForbidden
+}
+
+// invalidate drops token unless it has already been replaced by a concurrent refresh.
+func (s *csrfState) invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token == token {
+		s.token = ""
+	}
+}
+
+// csrfToken returns the cached CSRF token, fetching it when absent.
+func (c *Client) csrfToken(ctx context.Context) (string, error) {
+	c.csrf.mu.Lock()
+	defer c.csrf.mu.Unlock()
+
+	if c.csrf.token != "" {
+		return c.csrf.token, nil
+	}
+
+	token, err := c.fetchCSRFToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.csrf.token = token
+
+	return token, nil
+}
+
+func (c *Client) fetchCSRFToken(ctx context.Context) (string, error) {
+	cfg := c.csrf.config
+
+	req, err := c.newRequest(ctx, http.MethodGet, cfg.Path, nil, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.exchange(req)
+	if resp != nil {
+		defer func() {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	token, err := readCSRFToken(resp, cfg)
+	if err != nil {
+		return "", newInternalError("csrf", err)
+	}
+
+	return token, nil
+}
+
+func readCSRFToken(resp *http.Response, cfg CSRFConfig) (string, error) {
+	var token string
+	switch cfg.Source {
+	case CSRFSourceBody:
+		var body map[string]any
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return "", fmt.Errorf("failed to decode token response: %w", err)
+		}
+		token, _ = body[cfg.Name].(string)
+	case CSRFSourceHeader:
+		token = resp.Header.Get(cfg.Name)
+	case CSRFSourceCookie:
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == cfg.Name {
+				token = cookie.Value
+				break
+			}
+		}
+	}
+
+	if token == "" {
+		return "", fmt.Errorf("%w: %q is missing in response", ErrCSRFToken, cfg.Name)
+	}
+
+	return token, nil
+}
+
+// sendWithCSRF attaches the CSRF token to req and sends it, refreshing the
+// token and resending once when the server rejects it.
+func (c *Client) sendWithCSRF(req *http.Request, response any) (*Response, error) {
+	ctx := req.Context()
+	header := c.csrf.config.Header
+
+	token, err := c.csrfToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(header, token)
+
+	meta, err := c.send(req, response)
+	if !c.csrf.isFailure(err) {
+		return meta, err
+	}
+
+	next, rewindErr := rewindRequest(req)
+	if rewindErr != nil {
+		return meta, err
+	}
+
+	c.csrf.invalidate(token)
+	if token, err = c.csrfToken(ctx); err != nil {
+		return meta, err
+	}
+	next.Header.Set(header, token)
+
+	return c.send(next, response)
+}
+
+// isSafeMethod reports whether method is safe as defined by RFC 9110.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
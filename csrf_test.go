@@ -0,0 +1,116 @@
+package restkit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+func setupCSRFServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var issued atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		valid := "token-" + strconv.Itoa(int(issued.Load()))
+
+		switch r.URL.Path {
+		case "/csrf":
+			n := issued.Add(1)
+			token := "token-" + strconv.Itoa(int(n))
+			w.Header().Set("X-Issued-Token", token)
+			http.SetCookie(w, &http.Cookie{Name: "csrf", Value: token})
+			_, _ = w.Write([]byte(`{"token": "` + token + `"}`))
+		case "/expire":
+			// Invalidate the current token by issuing a new one server-side.
+			issued.Add(1)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			if r.Method != http.MethodGet && r.Header.Get("X-Csrf-Token") != valid {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+
+	return httpServer, &issued
+}
+
+func TestCSRF_Sources(t *testing.T) {
+	t.Parallel()
+
+	sources := map[string]rest.CSRFConfig{
+		"body":   {Path: "/csrf", Source: rest.CSRFSourceBody, Name: "token"},
+		"header": {Path: "/csrf", Source: rest.CSRFSourceHeader, Name: "X-Issued-Token"},
+		"cookie": {Path: "/csrf", Source: rest.CSRFSourceCookie, Name: "csrf"},
+	}
+
+	for name, cfg := range sources {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			httpServer, issued := setupCSRFServer(t)
+			defer httpServer.Close()
+
+			client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL, CSRF: &cfg})
+
+			for range 2 {
+				if err := client.Do(context.Background(), http.MethodPost, "/items", nil, nil, nil); err != nil {
+					t.Fatalf("Do() error = %v", err)
+				}
+			}
+			if issued.Load() != 1 {
+				t.Errorf("Expected token to be fetched once and reused, fetched %d times", issued.Load())
+			}
+		})
+	}
+}
+
+func TestCSRF_RefreshOnForbidden(t *testing.T) {
+	t.Parallel()
+
+	httpServer, issued := setupCSRFServer(t)
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		CSRF:    &rest.CSRFConfig{Path: "/csrf", Source: rest.CSRFSourceBody, Name: "token"},
+	})
+
+	ctx := context.Background()
+	if err := client.Do(ctx, http.MethodPost, "/items", nil, nil, nil); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if err := client.Do(ctx, http.MethodGet, "/expire", nil, nil, nil); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if err := client.Do(ctx, http.MethodDelete, "/items", nil, map[string]int{"id": 1}, nil); err != nil {
+		t.Fatalf("Expected token refresh after 403, got %v", err)
+	}
+	if issued.Load() != 3 {
+		t.Errorf("Expected 3 issued tokens, got %d", issued.Load())
+	}
+}
+
+func TestCSRF_MissingToken(t *testing.T) {
+	t.Parallel()
+
+	httpServer, _ := setupCSRFServer(t)
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		CSRF:    &rest.CSRFConfig{Path: "/csrf", Source: rest.CSRFSourceBody, Name: "missing"},
+	})
+
+	err := client.Do(context.Background(), http.MethodPost, "/items", nil, nil, nil)
+	if !rest.IsInternalError(err) || !errors.Is(err, rest.ErrCSRFToken) {
+		t.Errorf("Expected internal ErrCSRFToken error, got %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package restkit
+
+import (
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// CSVContentType is the media type decoded by CSVDecoder by default.
+const CSVContentType = "text/csv"
+
+// CSVDecoder decodes CSV responses with a header row into a pointer to a slice
+// of structs or struct pointers. Columns are matched to exported fields by their
+// `csv` tag, or case-insensitively by field name when untagged; a tag of "-"
+// skips the field and columns without a field are ignored. Fields may be strings,
+// booleans, numbers, encoding.TextUnmarshaler implementations or pointers to them.
+// Empty cells leave the field at its zero value.
+//
+// It is used for `text/csv` responses unless Config.Decoders registers another
+// decoder. Register a CSVDecoder with a different Comma to change the delimiter.
+type CSVDecoder struct {
+	Comma rune // Optional field delimiter, defaults to ','
+}
+
+// Decode reads the CSV stream from r into v.
+func (d CSVDecoder) Decode(r io.Reader, v any) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Pointer || target.IsNil() || target.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("%w: expected a pointer to a slice, got %T", ErrInvalidCSV, v)
+	}
+	slice := target.Elem()
+	elemType := slice.Type().Elem()
+	if indirectType(elemType).Kind() != reflect.Struct {
+		return fmt.Errorf("%w: expected a slice of structs, got %s", ErrInvalidCSV, slice.Type())
+	}
+
+	reader := csv.NewReader(r)
+	if d.Comma != 0 {
+		reader.Comma = d.Comma
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return err //nolint:wrapcheck // io.EOF marks an empty body
+	}
+	columns := csvColumns(indirectType(elemType), header)
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidCSV, err)
+		}
+
+		elem := reflect.New(indirectType(elemType)).Elem()
+		for i, field := range columns {
+			if field == nil || i >= len(record) || record[i] == "" {
+				continue
+			}
+			if err := parseCSVValue(fieldByIndex(elem, field), record[i]); err != nil {
+				line, _ := reader.FieldPos(i)
+				return fmt.Errorf("%w: line %d, column %q: %w", ErrInvalidCSV, line, header[i], err)
+			}
+		}
+
+		if elemType.Kind() == reflect.Pointer {
+			elem = elem.Addr()
+		}
+		slice.Set(reflect.Append(slice, elem))
+	}
+}
+
+// csvColumns returns the index of the field of t for every header column,
+// or nil for columns without a field.
+func csvColumns(t reflect.Type, header []string) [][]int {
+	columns := make([][]int, len(header))
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous || !canAllocatePath(t, field.Index) {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("csv"), ",")
+		if name == "-" {
+			continue
+		}
+		for i, column := range header {
+			if columns[i] != nil {
+				continue
+			}
+			column = strings.TrimSpace(column)
+			if (name != "" && column == name) || (name == "" && strings.EqualFold(column, field.Name)) {
+				columns[i] = field.Index
+			}
+		}
+	}
+	return columns
+}
+
+// canAllocatePath reports whether the embedded struct pointers on the way to the
+// field of t at index can be allocated, which unexported ones cannot.
+func canAllocatePath(t reflect.Type, index []int) bool {
+	for i := 1; i < len(index); i++ {
+		embedded := t.FieldByIndex(index[:i])
+		if embedded.Type.Kind() == reflect.Pointer && !embedded.IsExported() {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldByIndex returns the field of v at index like reflect.Value.FieldByIndex,
+// allocating nil embedded struct pointers on the way.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// parseCSVValue sets fv from the text of a CSV cell.
+func parseCSVValue(fv reflect.Value, s string) error {
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return parseCSVValue(fv.Elem(), s)
+	}
+	if u, ok := fv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s)) //nolint:wrapcheck // wrapped by the caller
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err //nolint:wrapcheck // wrapped by the caller
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err //nolint:wrapcheck // wrapped by the caller
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err //nolint:wrapcheck // wrapped by the caller
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return err //nolint:wrapcheck // wrapped by the caller
+		}
+		fv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type()) //nolint:err113 // wrapped by the caller
+	}
+
+	return nil
+}
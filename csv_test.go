@@ -0,0 +1,145 @@
+package restkit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+type reportRow struct {
+	Name    string    `csv:"name"`
+	Count   int       `csv:"count"`
+	Ratio   *float64  `csv:"ratio"`
+	Active  bool      `csv:"active"`
+	Day     time.Time `csv:"day"`
+	Comment string
+	Skipped string `csv:"-"`
+}
+
+func TestCSVDecoder(t *testing.T) {
+	t.Parallel()
+
+	ratio := 0.5
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		decoder rest.CSVDecoder
+		input   string
+		want    []reportRow
+		wantErr error
+	}{
+		{
+			name: "Quoted fields",
+			input: "name,count,ratio,active,day,comment,skipped\n" +
+				"\"Smith, John\",3,0.5,true,2024-03-01T00:00:00Z,\"multi\nline \"\"quoted\"\"\",x\n" +
+				"plain,,,false,2024-03-01T00:00:00Z,,\n",
+			want: []reportRow{
+				{Name: "Smith, John", Count: 3, Ratio: &ratio, Active: true, Day: day, Comment: "multi\nline \"quoted\""},
+				{Name: "plain", Day: day},
+			},
+		},
+		{
+			name:    "Delimiter",
+			decoder: rest.CSVDecoder{Comma: ';'},
+			input:   "count;name;unknown\n7;\"a;b\";z\n",
+			want:    []reportRow{{Name: "a;b", Count: 7}},
+		},
+		{
+			name:  "Header only",
+			input: "name,count\n",
+			want:  nil,
+		},
+		{
+			name:    "Invalid number",
+			input:   "name,count\na,many\n",
+			wantErr: rest.ErrInvalidCSV,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []reportRow
+			err := tt.decoder.Decode(strings.NewReader(tt.input), &got)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Decode() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVDecoder_InvalidTarget(t *testing.T) {
+	t.Parallel()
+
+	var target map[string]string
+	if err := (rest.CSVDecoder{}).Decode(strings.NewReader("a\n1\n"), &target); !errors.Is(err, rest.ErrInvalidCSV) {
+		t.Errorf("Expected ErrInvalidCSV, got %v", err)
+	}
+}
+
+type CSVBase struct {
+	ID int `csv:"id"`
+}
+
+type csvHidden struct {
+	Secret string `csv:"secret"`
+}
+
+func TestCSVDecoder_EmbeddedPointer(t *testing.T) {
+	t.Parallel()
+
+	var rows []struct {
+		*CSVBase
+		*csvHidden
+
+		Name string `csv:"name"`
+	}
+	err := (rest.CSVDecoder{}).Decode(strings.NewReader("id,secret,name\n7,x,widget\n"), &rows)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(rows) != 1 || rows[0].CSVBase == nil || rows[0].ID != 7 || rows[0].Name != "widget" || rows[0].csvHidden != nil {
+		t.Errorf("Unexpected rows: %+v", rows)
+	}
+}
+
+func TestClient_CSVResponse(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		_, _ = w.Write([]byte("name,count\n\"x, y\",2\n"))
+	}))
+	defer server.Close()
+
+	client, err := rest.NewClient(rest.Config{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	var rows []*reportRow
+	if err := client.Do(context.Background(), http.MethodGet, "/report", nil, nil, &rows); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if len(rows) != 1 || rows[0].Name != "x, y" || rows[0].Count != 2 {
+		t.Errorf("Unexpected rows %+v", rows)
+	}
+}
@@ -0,0 +1,116 @@
+package restkit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+)
+
+// redactedValue replaces the values of sensitive headers in debug dumps.
+const redactedValue = "[REDACTED]"
+
+// debugRedactedHeaders are masked in debug dumps.
+//
+//nolint:gochecknoglobals // read-only list
+var debugRedactedHeaders = append([]string{"Set-Cookie", "X-Api-Key"}, sensitiveHeaders...)
+
+// streamingMediaTypes are response media types whose bodies are consumed
+// incrementally and are therefore never buffered into a debug dump.
+//
+//nolint:gochecknoglobals // read-only set
+var streamingMediaTypes = map[string]bool{
+	"text/event-stream":       true,
+	"application/x-ndjson":    true,
+	"application/jsonl":       true,
+	"application/json-seq":    true,
+	"application/stream+json": true,
+}
+
+// streamingKey marks the context of requests made by the streaming APIs.
+type streamingKey struct{}
+
+// withStreaming marks ctx as belonging to a request whose response is read as a stream.
+func withStreaming(ctx context.Context) context.Context {
+	return context.WithValue(ctx, streamingKey{}, true)
+}
+
+// isStreamingResponse reports whether the body of resp is meant to be read as a stream.
+func isStreamingResponse(resp *http.Response) bool {
+	if resp.Request != nil {
+		if streaming, _ := resp.Request.Context().Value(streamingKey{}).(bool); streaming {
+			return true
+		}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return streamingMediaTypes[mediaType]
+}
+
+// debugDumper writes requests and responses to an output for troubleshooting.
+type debugDumper struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func newDebugDumper(out io.Writer) *debugDumper {
+	return &debugDumper{mu: sync.Mutex{}, out: out}
+}
+
+// dumpRequest writes req as sent on the wire. A one-shot body is buffered and
+// restored so that the request can still be sent.
+func (d *debugDumper) dumpRequest(req *http.Request) error {
+	header := req.Header
+	req.Header = redactHeaders(header)
+	dump, err := httputil.DumpRequestOut(req, true)
+	req.Header = header
+	if err != nil {
+		return fmt.Errorf("failed to dump request: %w", err)
+	}
+
+	d.write("request", dump)
+	return nil
+}
+
+// dumpResponse writes resp with its body, which is buffered and restored so
+// that it can still be decoded. Only the headers of streaming responses are
+// written, as reading their body would block until the stream ends.
+func (d *debugDumper) dumpResponse(resp *http.Response) error {
+	streaming := isStreamingResponse(resp)
+
+	header := resp.Header
+	resp.Header = redactHeaders(header)
+	dump, err := httputil.DumpResponse(resp, !streaming)
+	resp.Header = header
+	if err != nil {
+		return fmt.Errorf("failed to dump response: %w", err)
+	}
+	if streaming {
+		dump = append(dump, "[streaming body omitted]\n"...)
+	}
+
+	d.write("response", dump)
+	return nil
+}
+
+func (d *debugDumper) write(kind string, dump []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, _ = fmt.Fprintf(d.out, "---- %s ----\n%s\n", kind, dump)
+}
+
+// redactHeaders returns a copy of header with sensitive values masked.
+func redactHeaders(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, name := range debugRedactedHeaders {
+		values := redacted[http.CanonicalHeaderKey(name)]
+		for i := range values {
+			values[i] = redactedValue
+		}
+	}
+	return redacted
+}
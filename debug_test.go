@@ -0,0 +1,102 @@
+package restkit_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+func TestClient_Debug(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "cookie-secret"})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":42}`))
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	client, err := rest.NewClient(rest.Config{BaseURL: server.URL, Debug: true, DebugOutput: &out})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	headers := http.Header{"Authorization": {"Bearer token-secret"}}
+	var resp struct {
+		ID int `json:"id"`
+	}
+	if err := client.Do(context.Background(), http.MethodPost, "/items", headers, map[string]string{"name": "widget"}, &resp); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if resp.ID != 42 {
+		t.Errorf("Expected the dumped response to still decode, got %+v", resp)
+	}
+
+	dump := out.String()
+	for _, want := range []string{"POST /items HTTP/1.1", `{"name":"widget"}`, "HTTP/1.1 200 OK", `{"id":42}`, "Authorization: [REDACTED]", "Set-Cookie: [REDACTED]"} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("Expected dump to contain %q, got:\n%s", want, dump)
+		}
+	}
+	for _, secret := range []string{"token-secret", "cookie-secret"} {
+		if strings.Contains(dump, secret) {
+			t.Errorf("Expected %q to be redacted, got:\n%s", secret, dump)
+		}
+	}
+}
+
+func TestClient_DebugDisabled(t *testing.T) {
+	server := setupTestServer(t)
+	defer server.Close()
+
+	var out bytes.Buffer
+	client, _ := rest.NewClient(rest.Config{BaseURL: server.URL, DebugOutput: &out})
+	_ = client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+
+	if out.Len() != 0 {
+		t.Errorf("Expected no dump without Debug, got:\n%s", out.String())
+	}
+}
+
+func TestClient_DebugStreaming(t *testing.T) {
+	errStop := errors.New("stop")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/events" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: first\n\n"))
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id":1}`))
+		}
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	client, _ := rest.NewClient(rest.Config{BaseURL: server.URL, Debug: true, DebugOutput: &out})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	err := client.Subscribe(ctx, "/events", nil, func(rest.Event) error { return errStop })
+	if !errors.Is(err, errStop) {
+		t.Errorf("Subscribe() error = %v, want the first event to be delivered", err)
+	}
+
+	err = rest.DoStreamJSON(ctx, client, http.MethodGet, "/items", nil, nil, func(struct{ ID int }) error { return errStop })
+	if !errors.Is(err, errStop) {
+		t.Errorf("DoStreamJSON() error = %v, want the first item to be delivered", err)
+	}
+
+	if got := strings.Count(out.String(), "[streaming body omitted]"); got != 2 {
+		t.Errorf("Expected both streaming bodies to be omitted from the dump, got:\n%s", out.String())
+	}
+}
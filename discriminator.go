@@ -0,0 +1,48 @@
+package restkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// DecodeDiscriminated decodes a JSON object into the type registered for the
+// value of its discriminator field, e.g. the `type` of an event. The registry
+// maps discriminator values to functions returning a pointer to decode into,
+// which is returned. A missing or non-string field or an unregistered value
+// fails with ErrDiscriminator.
+//
+//	event, err := rest.DecodeDiscriminated(body, "type", map[string]func() any{
+//		"created": func() any { return &Created{} },
+//		"deleted": func() any { return &Deleted{} },
+//	})
+func DecodeDiscriminated(body []byte, field string, registry map[string]func() any) (any, error) {
+	body = bytes.TrimPrefix(body, utf8BOM)
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnmarshalJSON, err)
+	}
+
+	raw, ok := fields[field]
+	if !ok {
+		return nil, fmt.Errorf("%w: field %q is missing", ErrDiscriminator, field)
+	}
+
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, fmt.Errorf("%w: field %q is not a string: %s", ErrDiscriminator, field, raw)
+	}
+
+	factory, ok := registry[value]
+	if !ok {
+		return nil, fmt.Errorf("%w: no type registered for %s %q", ErrDiscriminator, field, value)
+	}
+
+	target := factory()
+	if err := json.Unmarshal(body, target); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnmarshalJSON, err)
+	}
+
+	return target, nil
+}
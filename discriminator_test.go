@@ -0,0 +1,90 @@
+package restkit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+type createdEvent struct {
+	Type string `json:"type"`
+	ID   int    `json:"id"`
+}
+
+type renamedEvent struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+func eventRegistry() map[string]func() any {
+	return map[string]func() any{
+		"created": func() any { return &createdEvent{} },
+		"renamed": func() any { return &renamedEvent{} },
+	}
+}
+
+func TestDecodeDiscriminated(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    any
+		wantErr error
+	}{
+		{name: "Created", body: `{"type":"created","id":7}`, want: &createdEvent{Type: "created", ID: 7}},
+		{name: "Renamed", body: `{"name":"new","type":"renamed"}`, want: &renamedEvent{Type: "renamed", Name: "new"}},
+		{name: "Missing field", body: `{"id":7}`, wantErr: rest.ErrDiscriminator},
+		{name: "Not a string", body: `{"type":1}`, wantErr: rest.ErrDiscriminator},
+		{name: "Unregistered", body: `{"type":"deleted"}`, wantErr: rest.ErrDiscriminator},
+		{name: "Invalid JSON", body: `[1]`, wantErr: rest.ErrUnmarshalJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rest.DecodeDiscriminated([]byte(tt.body), "type", eventRegistry())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DecodeDiscriminated() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			switch want := tt.want.(type) {
+			case *createdEvent:
+				if got, ok := got.(*createdEvent); !ok || *got != *want {
+					t.Errorf("DecodeDiscriminated() = %#v, want %#v", got, want)
+				}
+			case *renamedEvent:
+				if got, ok := got.(*renamedEvent); !ok || *got != *want {
+					t.Errorf("DecodeDiscriminated() = %#v, want %#v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeDiscriminated_Response(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"type":"renamed","name":"new"}`))
+	}))
+	defer server.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: server.URL})
+
+	var body []byte
+	if err := client.Do(context.Background(), http.MethodGet, "/events/1", nil, nil, &body); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	event, err := rest.DecodeDiscriminated(body, "type", eventRegistry())
+	if err != nil {
+		t.Fatalf("DecodeDiscriminated() error = %v", err)
+	}
+	if renamed, ok := event.(*renamedEvent); !ok || renamed.Name != "new" {
+		t.Errorf("Expected a renamed event, got %#v", event)
+	}
+}
@@ -1,16 +1,38 @@
 package restkit
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 )
 
 var (
-	ErrInvalidConfig  = errors.New("rest: invalid config")
-	ErrEmptyMethod    = errors.New("rest: empty method")
-	ErrEmptyErrorBody = errors.New("rest: empty error body")
-	ErrUnmarshalJSON  = errors.New("rest: failed to unmarshal body")
+	ErrInvalidConfig        = errors.New("rest: invalid config")
+	ErrEmptyMethod          = errors.New("rest: empty method")
+	ErrEmptyErrorBody       = errors.New("rest: empty error body")
+	ErrUnmarshalJSON        = errors.New("rest: failed to unmarshal body")
+	ErrCSRFToken            = errors.New("rest: CSRF token not found")
+	ErrResponseTooLarge     = errors.New("rest: response body too large")
+	ErrBodyNotReplayable    = errors.New("rest: request body cannot be replayed")
+	ErrInvalidPointer       = errors.New("rest: invalid JSON pointer")
+	ErrRequestTooLarge      = errors.New("rest: request body too large")
+	ErrUnsupportedEncoding  = errors.New("rest: unsupported content encoding")
+	ErrInvalidQuery         = errors.New("rest: invalid query")
+	ErrTimeout              = errors.New("rest: request timed out")
+	ErrNotJSON              = errors.New("rest: error body is not JSON")
+	ErrUnsupportedMediaType = errors.New("rest: unsupported media type")
+	ErrInvalidCSV           = errors.New("rest: invalid CSV")
+	ErrInsecureRedirect     = errors.New("rest: redirect from https to http")
+	ErrTooManyRedirects     = errors.New("rest: too many redirects")
+	ErrClosed               = errors.New("rest: client closed")
+	ErrDiscriminator        = errors.New("rest: invalid discriminator")
+	ErrEnvelopeField        = errors.New("rest: envelope field missing")
+	ErrSchemaViolation      = errors.New("rest: payload does not conform to the schema")
+	ErrNilToken             = errors.New("rest: token source returned nil token")
+	ErrNilPatch             = errors.New("rest: nil JSON patch")
 )
 
 // ErrorWithBody provides access to raw error response bodies.
@@ -22,11 +44,16 @@ type ErrorWithBody interface {
 
 // InternalError represents errors in request construction
 type InternalError struct {
-	Err error  // Underlying error
-	Op  string // Operation where error occurred
+	Err    error  // Underlying error
+	Op     string // Operation where error occurred
+	Method string // HTTP method of the request, if known
+	URL    string // Resolved URL of the request, or the requested path when it could not be resolved
 }
 
 func (e *InternalError) Error() string {
+	if e.Method != "" {
+		return fmt.Sprintf("rest: %s: %s %s: %v", e.Op, e.Method, e.URL, e.Err)
+	}
 	return fmt.Sprintf("rest: %s: %v", e.Op, e.Err)
 }
 
@@ -34,36 +61,112 @@ func (e *InternalError) Unwrap() error { return e.Err }
 
 // newInternalError creates a new InternalError
 func newInternalError(op string, err error) *InternalError {
-	return &InternalError{Err: err, Op: op}
+	return &InternalError{Err: err, Op: op, Method: "", URL: ""}
+}
+
+// DecodeError represents a failure to decode a successful response body.
+// It is returned wrapped in an InternalError.
+type DecodeError struct {
+	Err  error  // Underlying decode error
+	Body []byte // Response body, truncated to the first 8 KiB
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode response: %v", e.Err)
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// AsDecodeError attempts to extract a DecodeError from an error chain
+func AsDecodeError(err error) (*DecodeError, bool) {
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) {
+		return decodeErr, true
+	}
+	return nil, false
 }
 
 // InfrastructureError represents network-level failures
 type InfrastructureError struct {
-	Err error
-	URL string
+	Err      error
+	URL      string
+	Method   string // HTTP method of the request, if known
+	Attempts int    // Number of attempts made when the request was retried, zero otherwise
 }
 
 func (e *InfrastructureError) Error() string {
-	return fmt.Sprintf("rest: infrastructure error contacting %s: %v", e.URL, e.Err)
+	if e.Method != "" {
+		return fmt.Sprintf("rest: infrastructure error on %s %s: %v%s", e.Method, e.URL, e.Err, attemptsSuffix(e.Attempts))
+	}
+	return fmt.Sprintf("rest: infrastructure error contacting %s: %v%s", e.URL, e.Err, attemptsSuffix(e.Attempts))
 }
 
 func (e *InfrastructureError) Unwrap() error { return e.Err }
 
+// Timeout reports whether the failure was caused by a deadline or timeout,
+// such as an expired context deadline or the http.Client timeout.
+func (e *InfrastructureError) Timeout() bool {
+	if errors.Is(e.Err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var timeout interface{ Timeout() bool }
+	return errors.As(e.Err, &timeout) && timeout.Timeout()
+}
+
+// Is reports whether the error matches target. An InfrastructureError matches
+// ErrTimeout when the failure was caused by a deadline or timeout.
+func (e *InfrastructureError) Is(target error) bool {
+	return target == ErrTimeout && e.Timeout()
+}
+
+// Canceled reports whether the failure was caused by cancellation of the request context.
+func (e *InfrastructureError) Canceled() bool {
+	return errors.Is(e.Err, context.Canceled)
+}
+
 // newInfrastructureError creates a new InfrastructureError
 func newInfrastructureError(url string, err error) *InfrastructureError {
-	return &InfrastructureError{Err: err, URL: url}
+	return &InfrastructureError{Err: err, URL: url, Method: "", Attempts: 0}
+}
+
+// annotateError records the method and URL of the request in the InternalError
+// or InfrastructureError found in err, unless they are already set.
+func annotateError(err error, method, url string) {
+	var internalErr *InternalError
+	if errors.As(err, &internalErr) && internalErr.Method == "" {
+		internalErr.Method = method
+		internalErr.URL = url
+	}
+
+	var infraErr *InfrastructureError
+	if errors.As(err, &infraErr) && infraErr.Method == "" {
+		infraErr.Method = method
+	}
 }
 
 // APIError represents server responses with error status codes
 type APIError struct {
-	StatusCode int    // HTTP status code
-	URL        string // URL of the request
-	Body       []byte // Raw error response body
+	StatusCode  int    // HTTP status code
+	URL         string // URL of the request
+	Body        []byte // Raw error response body
+	ContentType string // Content-Type header of the response, if any
+	Attempts    int    // Number of attempts made when the request was retried, zero otherwise
+
+	errorField string // envelope field holding the error details, if any
 }
 
 func (e *APIError) Error() string {
-	return fmt.Sprintf("rest: API error %d from %s: %s",
-		e.StatusCode, e.URL, string(e.Body))
+	return fmt.Sprintf("rest: API error %d from %s: %s%s",
+		e.StatusCode, e.URL, string(e.Body), attemptsSuffix(e.Attempts))
+}
+
+// attemptsSuffix describes the attempts of a retried request in error messages.
+func attemptsSuffix(attempts int) string {
+	if attempts < 2 { //nolint:mnd // a single attempt is not worth mentioning
+		return ""
+	}
+	return fmt.Sprintf(" (after %d attempts)", attempts)
 }
 
 // RawBody returns the raw error response body
@@ -71,17 +174,48 @@ func (e *APIError) RawBody() []byte {
 	return e.Body
 }
 
-// ParseError attempts to parse the error body into the provided struct
+// IsJSON reports whether the Content-Type of the response is JSON, either
+// `application/json` or a `+json` media type such as `application/problem+json`.
+func (e *APIError) IsJSON() bool {
+	return isJSONMediaType(MediaType(e.ContentType))
+}
+
+// ParseError attempts to parse the error body into the provided struct.
+// When the body cannot be parsed and the response declared a non-JSON
+// Content-Type, such as an HTML gateway page, the error matches ErrNotJSON.
+// With Config.UnwrapErrorField set, only that field of the body is parsed.
 func (e *APIError) ParseError(target any) error {
 	if len(e.Body) == 0 {
 		return ErrEmptyErrorBody
 	}
-	if err := json.Unmarshal(e.Body, target); err != nil {
-		return fmt.Errorf("%w: %w", ErrUnmarshalJSON, err)
+
+	body := bytes.TrimPrefix(e.Body, utf8BOM)
+	if e.errorField != "" {
+		var envelope map[string]json.RawMessage
+		if err := json.Unmarshal(body, &envelope); err != nil {
+			return e.unmarshalError(err)
+		}
+		field, ok := envelope[e.errorField]
+		if !ok {
+			return fmt.Errorf("%w: %q", ErrEnvelopeField, e.errorField)
+		}
+		body = field
+	}
+
+	if err := json.Unmarshal(body, target); err != nil {
+		return e.unmarshalError(err)
 	}
 	return nil
 }
 
+// unmarshalError wraps a failure to unmarshal the body.
+func (e *APIError) unmarshalError(err error) error {
+	if e.ContentType != "" && !e.IsJSON() {
+		return fmt.Errorf("%w: got %s: %w", ErrNotJSON, MediaType(e.ContentType), err)
+	}
+	return fmt.Errorf("%w: %w", ErrUnmarshalJSON, err)
+}
+
 // AsAPIError attempts to extract an APIError from an error chain
 func AsAPIError(err error) (*APIError, bool) {
 	var apiErr *APIError
@@ -103,6 +237,11 @@ func IsInfrastructureError(err error) bool {
 	return errors.As(err, &target)
 }
 
+// IsTimeoutError checks if error is an infrastructure error caused by a deadline or timeout
+func IsTimeoutError(err error) bool {
+	return errors.Is(err, ErrTimeout)
+}
+
 // IsAPIError checks if an error is an API error with a response body
 func IsAPIError(err error) bool {
 	var target ErrorWithBody
@@ -127,5 +266,42 @@ func IsServerError(err error) bool {
 	return ok && apiErr.StatusCode >= 500 && apiErr.StatusCode < 600
 }
 
+// IsNotFound reports whether err is an API error with status 404 Not Found.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsConflict reports whether err is an API error with status 409 Conflict.
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+// IsUnauthorized reports whether err is an API error with status 401 Unauthorized.
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+// IsForbidden reports whether err is an API error with status 403 Forbidden.
+func IsForbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
+
+// IsTooManyRequests reports whether err is an API error with status 429 Too Many Requests.
+func IsTooManyRequests(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
+
+// IsUnprocessable reports whether err is an API error with status 422 Unprocessable Entity.
+func IsUnprocessable(err error) bool {
+	return hasStatus(err, http.StatusUnprocessableEntity)
+}
+
+// hasStatus reports whether err is an API error with the given status code.
+func hasStatus(err error, statusCode int) bool {
+	apiErr, ok := AsAPIError(err)
+
+	return ok && apiErr.StatusCode == statusCode
+}
+
 // Ensure APIError implements ErrorWithBody.
 var _ ErrorWithBody = (*APIError)(nil)
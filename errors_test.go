@@ -1,9 +1,16 @@
 package restkit_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	liberr "github.com/capcom6/go-restkit"
 )
@@ -57,6 +64,111 @@ func TestInfrastructureError(t *testing.T) {
 	}
 }
 
+func TestClient_ErrorRequestContext(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("not json"))
+	}))
+	defer server.Close()
+
+	client, err := liberr.NewClient(liberr.Config{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	var target map[string]any
+	err = client.Do(context.Background(), http.MethodGet, "/items?id=1", nil, nil, &target)
+	var internalErr *liberr.InternalError
+	if !errors.As(err, &internalErr) {
+		t.Fatalf("Expected InternalError, got %v", err)
+	}
+	if internalErr.Method != http.MethodGet || internalErr.URL != server.URL+"/items?id=1" {
+		t.Errorf("Expected GET %s/items?id=1, got %s %s", server.URL, internalErr.Method, internalErr.URL)
+	}
+	if want := "rest: DoRAW: GET " + server.URL + "/items?id=1: "; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("Expected message to start with %q, got %q", want, err.Error())
+	}
+
+	unreachable, _ := liberr.NewClient(liberr.Config{BaseURL: "http://localhost:1"})
+	err = unreachable.Do(context.Background(), http.MethodDelete, "/items/1", nil, nil, nil)
+	var infraErr *liberr.InfrastructureError
+	if !errors.As(err, &infraErr) {
+		t.Fatalf("Expected InfrastructureError, got %v", err)
+	}
+	if infraErr.Method != http.MethodDelete {
+		t.Errorf("Expected method %s, got %q", http.MethodDelete, infraErr.Method)
+	}
+	if want := "rest: infrastructure error on DELETE http://localhost:1/items/1: "; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("Expected message to start with %q, got %q", want, err.Error())
+	}
+}
+
+func TestInfrastructureError_TimeoutCanceled(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		err          error
+		wantTimeout  bool
+		wantCanceled bool
+	}{
+		{name: "deadline", err: context.DeadlineExceeded, wantTimeout: true},
+		{name: "wrapped deadline", err: fmt.Errorf("dial: %w", context.DeadlineExceeded), wantTimeout: true},
+		{name: "net timeout", err: &net.OpError{Op: "dial", Err: os.ErrDeadlineExceeded}, wantTimeout: true},
+		{name: "canceled", err: context.Canceled, wantCanceled: true},
+		{name: "dial failure", err: &net.OpError{Op: "dial", Err: errWrapped}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			infraErr := &liberr.InfrastructureError{Err: tt.err, URL: "http://example.com"}
+			if infraErr.Timeout() != tt.wantTimeout {
+				t.Errorf("Timeout() = %v, want %v", infraErr.Timeout(), tt.wantTimeout)
+			}
+			if infraErr.Canceled() != tt.wantCanceled {
+				t.Errorf("Canceled() = %v, want %v", infraErr.Canceled(), tt.wantCanceled)
+			}
+			if !errors.Is(infraErr, tt.err) {
+				t.Error("Expected InfrastructureError to wrap the original error")
+			}
+			if got := liberr.IsTimeoutError(fmt.Errorf("call: %w", infraErr)); got != tt.wantTimeout {
+				t.Errorf("IsTimeoutError() = %v, want %v", got, tt.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestClient_TimeoutError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client, err := liberr.NewClient(liberr.Config{
+		Client:  &http.Client{Timeout: 20 * time.Millisecond},
+		BaseURL: server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	err = client.Do(context.Background(), http.MethodGet, "/slow", nil, nil, nil)
+	if !errors.Is(err, liberr.ErrTimeout) {
+		t.Errorf("Expected ErrTimeout, got %v", err)
+	}
+	if !liberr.IsTimeoutError(err) || !liberr.IsInfrastructureError(err) {
+		t.Errorf("Expected a timeout infrastructure error, got %v", err)
+	}
+	if errors.Is(context.Canceled, liberr.ErrTimeout) {
+		t.Error("Expected unrelated errors not to match ErrTimeout")
+	}
+}
+
 func TestAPIError(t *testing.T) {
 	t.Parallel()
 
@@ -101,6 +213,71 @@ func TestAPIError(t *testing.T) {
 	}
 }
 
+func TestAPIError_ContentType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantJSON    bool
+		wantErr     error
+	}{
+		{name: "json", contentType: "application/json; charset=utf-8", body: `{"error":"x"}`, wantJSON: true},
+		{name: "problem json", contentType: "application/problem+json", body: `{"error":"x"}`, wantJSON: true},
+		{name: "html", contentType: "text/html", body: "<html>Bad Gateway</html>", wantErr: liberr.ErrNotJSON},
+		{name: "mislabeled json", contentType: "text/plain", body: `{"error":"x"}`},
+		{name: "unknown type", body: "Bad Gateway", wantErr: liberr.ErrUnmarshalJSON},
+		{name: "invalid json", contentType: "application/json", body: "{", wantJSON: true, wantErr: liberr.ErrUnmarshalJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			apiErr := &liberr.APIError{StatusCode: 502, URL: "http://example.com", Body: []byte(tt.body), ContentType: tt.contentType}
+			if apiErr.IsJSON() != tt.wantJSON {
+				t.Errorf("IsJSON() = %v, want %v", apiErr.IsJSON(), tt.wantJSON)
+			}
+
+			var parsed struct {
+				Error string `json:"error"`
+			}
+			err := apiErr.ParseError(&parsed)
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("ParseError() error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseError() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_APIErrorContentType(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("<html>Bad Gateway</html>"))
+	}))
+	defer server.Close()
+
+	client, err := liberr.NewClient(liberr.Config{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	apiErr, ok := liberr.AsAPIError(client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil))
+	if !ok {
+		t.Fatal("Expected APIError")
+	}
+	if apiErr.ContentType != "text/html; charset=utf-8" || apiErr.IsJSON() {
+		t.Errorf("Expected HTML content type, got %q", apiErr.ContentType)
+	}
+}
+
 func TestAsAPIError(t *testing.T) {
 	t.Parallel()
 
@@ -181,3 +358,87 @@ func TestIsAPIError(t *testing.T) {
 		t.Error("IsAPIError should return false for non-APIError")
 	}
 }
+
+func TestStatusPredicates(t *testing.T) {
+	t.Parallel()
+
+	predicates := map[string]struct {
+		fn     func(error) bool
+		status int
+	}{
+		"IsNotFound":        {liberr.IsNotFound, http.StatusNotFound},
+		"IsConflict":        {liberr.IsConflict, http.StatusConflict},
+		"IsUnauthorized":    {liberr.IsUnauthorized, http.StatusUnauthorized},
+		"IsForbidden":       {liberr.IsForbidden, http.StatusForbidden},
+		"IsTooManyRequests": {liberr.IsTooManyRequests, http.StatusTooManyRequests},
+		"IsUnprocessable":   {liberr.IsUnprocessable, http.StatusUnprocessableEntity},
+	}
+
+	for name, p := range predicates {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, status := range []int{400, 401, 403, 404, 409, 422, 429, 500} {
+				err := fmt.Errorf("call: %w", &liberr.APIError{StatusCode: status, URL: "http://example.com"})
+				if got := p.fn(err); got != (status == p.status) {
+					t.Errorf("%s() for status %d = %v", name, status, got)
+				}
+			}
+			if p.fn(errNotAPI) {
+				t.Errorf("%s() should return false for non-APIError", name)
+			}
+			if p.fn(nil) {
+				t.Errorf("%s() should return false for nil", name)
+			}
+		})
+	}
+}
+
+func TestClient_DecodeError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		body     string
+		wantBody string
+	}{
+		{name: "short body", body: `{"id": "oops`, wantBody: `{"id": "oops`},
+		{name: "html", body: "<html>maintenance</html>", wantBody: "<html>maintenance</html>"},
+		{name: "truncated", body: `{"id": x` + strings.Repeat("y", 10<<10), wantBody: (`{"id": x` + strings.Repeat("y", 10<<10))[:8<<10]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client, err := liberr.NewClient(liberr.Config{BaseURL: server.URL})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			var target struct {
+				ID string `json:"id"`
+			}
+			err = client.Do(context.Background(), http.MethodGet, "/", nil, nil, &target)
+			if !liberr.IsInternalError(err) {
+				t.Fatalf("Expected InternalError, got %v", err)
+			}
+
+			decodeErr, ok := liberr.AsDecodeError(err)
+			if !ok {
+				t.Fatalf("Expected DecodeError, got %v", err)
+			}
+			if string(decodeErr.Body) != tt.wantBody {
+				t.Errorf("Expected body of %d bytes, got %d bytes", len(tt.wantBody), len(decodeErr.Body))
+			}
+			if decodeErr.Err == nil {
+				t.Error("Expected underlying decode error")
+			}
+		})
+	}
+}
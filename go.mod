@@ -1,3 +1,8 @@
 module github.com/capcom6/go-restkit
 
 go 1.24.1
+
+require (
+	github.com/andybalholm/brotli v1.2.5
+	google.golang.org/protobuf v1.36.12
+)
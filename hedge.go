@@ -0,0 +1,119 @@
+package restkit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HedgingConfig configures hedged requests. When the response to a safe request
+// (GET, HEAD, OPTIONS, TRACE) has not arrived within Delay, another copy of the
+// request is sent, up to MaxHedges extra copies. The first response wins and the
+// remaining requests are canceled.
+type HedgingConfig struct {
+	Delay     time.Duration // Time to wait before sending each additional request
+	MaxHedges int           // Maximum number of additional requests, zero disables hedging
+}
+
+// hedgeResult is the outcome of a single hedged attempt.
+type hedgeResult struct {
+	resp  *http.Response
+	err   error
+	index int
+}
+
+// cancelOnClose cancels the context of the winning attempt once its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close() //nolint:wrapcheck // transparent wrapper
+}
+
+// hedgedExchange performs req, sending additional copies according to the hedging
+// configuration, and returns the first response received.
+// A transport failure is only returned when no other copy is still in flight.
+func (c *Client) hedgedExchange(req *http.Request) (*http.Response, error) {
+	if c.hedging.MaxHedges <= 0 || !isSafeMethod(req.Method) {
+		return c.exchange(req)
+	}
+
+	results := make(chan hedgeResult, c.hedging.MaxHedges+1)
+	cancels := make([]context.CancelFunc, 0, c.hedging.MaxHedges+1)
+	launch := func(r *http.Request) {
+		ctx, cancel := context.WithCancel(req.Context())
+		cancels = append(cancels, cancel)
+		index := len(cancels) - 1
+		// Every copy gets its own headers: exchange sets Authorization and
+		// signatures while req is still read to build the next copy.
+		r = r.Clone(ctx)
+		go func() {
+			resp, err := c.exchange(r)
+			results <- hedgeResult{resp: resp, err: err, index: index}
+		}()
+	}
+
+	launch(req)
+	inflight := 1
+
+	timer := time.NewTimer(c.hedging.Delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case res := <-results:
+			inflight--
+			if res.resp == nil && inflight > 0 {
+				cancels[res.index]()
+				continue
+			}
+
+			for i, cancel := range cancels {
+				if i != res.index {
+					cancel()
+				}
+			}
+			go discardHedges(results, inflight)
+
+			if res.resp == nil {
+				cancels[res.index]()
+				return nil, res.err
+			}
+			res.resp.Body = &cancelOnClose{ReadCloser: res.resp.Body, cancel: cancels[res.index]}
+			return res.resp, res.err
+
+		case <-timer.C:
+			if !c.canHedge(req, len(cancels)) {
+				continue
+			}
+			next, err := rewindRequest(req)
+			if err != nil {
+				continue
+			}
+			launch(next)
+			inflight++
+			timer.Reset(c.hedging.Delay)
+		}
+	}
+}
+
+// canHedge reports whether another copy of req may be sent after launched copies.
+func (c *Client) canHedge(req *http.Request, launched int) bool {
+	return launched <= c.hedging.MaxHedges && req.Context().Err() == nil
+}
+
+// discardHedges closes the responses of the n attempts that lost the race.
+func discardHedges(results <-chan hedgeResult, n int) {
+	for range n {
+		res := <-results
+		if res.resp != nil {
+			_, _ = io.Copy(io.Discard, res.resp.Body)
+			res.resp.Body.Close()
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package restkit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+// setupSlowFirstServer returns a server that stalls the first request until it is canceled.
+func setupSlowFirstServer(t testing.TB) (*httptest.Server, *atomic.Int32, *atomic.Bool) {
+	t.Helper()
+
+	var (
+		requests atomic.Int32
+		canceled atomic.Bool
+	)
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+				canceled.Store(true)
+			case <-time.After(2 * time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+
+	return httpServer, &requests, &canceled
+}
+
+func TestHedging(t *testing.T) {
+	httpServer, requests, canceled := setupSlowFirstServer(t)
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		Hedging: rest.HedgingConfig{Delay: 20 * time.Millisecond, MaxHedges: 1},
+	})
+
+	var resp struct {
+		OK bool `json:"ok"`
+	}
+	start := time.Now()
+	if err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, &resp); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	if !resp.OK {
+		t.Error("Expected the response of the hedged request")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the hedged request to win, took %v", elapsed)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Expected 2 requests, got %d", got)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !canceled.Load() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !canceled.Load() {
+		t.Error("Expected the losing request to be canceled")
+	}
+}
+
+func TestHedging_UnsafeMethod(t *testing.T) {
+	var requests atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		Hedging: rest.HedgingConfig{Delay: 5 * time.Millisecond, MaxHedges: 2},
+	})
+
+	if err := client.Do(context.Background(), http.MethodPost, "/", nil, map[string]string{}, nil); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected POST not to be hedged, got %d requests", got)
+	}
+}
+
+func TestHedging_Authorized(t *testing.T) {
+	httpServer, requests, _ := setupSlowFirstServer(t)
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		Hedging: rest.HedgingConfig{Delay: 20 * time.Millisecond, MaxHedges: 2},
+		TokenSource: rest.TokenSourceFunc(func() (*rest.Token, error) {
+			return &rest.Token{AccessToken: "token"}, nil
+		}),
+		SignRequest: func(req *http.Request, _ []byte) error {
+			req.Header.Set("X-Signature", "signed")
+			return nil
+		},
+	})
+
+	var resp struct {
+		OK bool `json:"ok"`
+	}
+	if err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, &resp); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if !resp.OK || requests.Load() < 2 {
+		t.Errorf("Expected a hedged request to win, got %+v after %d requests", resp, requests.Load())
+	}
+}
@@ -0,0 +1,60 @@
+package restkit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestIdempotencyKey(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL:        httpServer.URL,
+		IdempotencyKey: true,
+		Retry:          rest.RetryConfig{MaxAttempts: 3, Backoff: constantBackoff(time.Millisecond)},
+	})
+
+	_ = client.Do(context.Background(), http.MethodPost, "/charges", nil, map[string]int{"amount": 1}, nil)
+	if len(keys) != 3 || !uuidPattern.MatchString(keys[0]) || keys[1] != keys[0] || keys[2] != keys[0] {
+		t.Fatalf("Expected the same generated key on every attempt, got %v", keys)
+	}
+	first := keys[0]
+
+	keys = nil
+	_ = client.Do(context.Background(), http.MethodPost, "/charges", nil, nil, nil)
+	if len(keys) == 0 || keys[0] == first {
+		t.Errorf("Expected a new key for a new call, got %v", keys)
+	}
+
+	keys = nil
+	headers := http.Header{"Idempotency-Key": []string{"caller-key"}}
+	_ = client.Do(context.Background(), http.MethodPost, "/charges", headers, nil, nil)
+	if len(keys) == 0 || keys[0] != "caller-key" {
+		t.Errorf("Expected caller key to take precedence, got %v", keys)
+	}
+
+	keys = nil
+	_ = client.Do(context.Background(), http.MethodGet, "/charges", nil, nil, nil)
+	if len(keys) == 0 || keys[0] != "" {
+		t.Errorf("Expected no key for safe methods, got %v", keys)
+	}
+}
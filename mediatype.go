@@ -0,0 +1,34 @@
+package restkit
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	"strings"
+)
+
+// utf8BOM is the UTF-8 byte order mark some servers prepend to JSON bodies.
+//
+//nolint:gochecknoglobals // read-only value
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// MediaType returns the lowercased media type of a Content-Type header value
+// without its parameters, e.g. "application/json" for
+// "application/json; charset=utf-8". It returns an empty string for an empty value.
+func MediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, _, _ = strings.Cut(contentType, ";")
+	}
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+// skipBOM returns a reader of r without a leading UTF-8 byte order mark.
+func skipBOM(r io.Reader) io.Reader {
+	buffered := bufio.NewReader(r)
+	if prefix, err := buffered.Peek(len(utf8BOM)); err == nil && bytes.Equal(prefix, utf8BOM) {
+		_, _ = buffered.Discard(len(utf8BOM))
+	}
+	return buffered
+}
@@ -0,0 +1,44 @@
+package restkit
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Metrics receives an observation for every request made by the client.
+// It allows wiring Prometheus, OpenTelemetry, statsd or any other metrics
+// system without adding dependencies to this package.
+type Metrics interface {
+	// ObserveRequest is called once per request after it completes, including retries.
+	// statusCode is zero when no response was received.
+	ObserveRequest(method, path string, statusCode int, duration time.Duration, err error)
+}
+
+// InFlightMetrics is optionally implemented by a Metrics sink to track the number
+// of requests currently on the wire, e.g. as a gauge compared against the
+// connection pool limit. IncInFlight is called before every attempt is sent and
+// DecInFlight once its response body is closed or the attempt failed.
+type InFlightMetrics interface {
+	IncInFlight()
+	DecInFlight()
+}
+
+// NoopMetrics discards all observations.
+type NoopMetrics struct{}
+
+// ObserveRequest does nothing.
+func (NoopMetrics) ObserveRequest(string, string, int, time.Duration, error) {}
+
+// releaseOnClose calls release once the body is closed for the first time.
+type releaseOnClose struct {
+	io.ReadCloser
+
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseOnClose) Close() error {
+	defer b.once.Do(b.release)
+	return b.ReadCloser.Close() //nolint:wrapcheck // transparent wrapper
+}
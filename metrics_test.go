@@ -0,0 +1,136 @@
+package restkit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+type observation struct {
+	method     string
+	path       string
+	statusCode int
+	duration   time.Duration
+	err        error
+}
+
+type recordingMetrics struct {
+	mu           sync.Mutex
+	observations []observation
+}
+
+func (m *recordingMetrics) ObserveRequest(method, path string, statusCode int, duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observations = append(m.observations, observation{method, path, statusCode, duration, err})
+}
+
+func TestMetrics_ObserveRequest(t *testing.T) {
+	httpServer := setupTestServer(t)
+	defer httpServer.Close()
+
+	metrics := &recordingMetrics{}
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL, Metrics: metrics})
+
+	_ = client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	_ = client.Do(context.Background(), http.MethodGet, "/404", nil, nil, nil)
+
+	unreachable, _ := rest.NewClient(rest.Config{BaseURL: "http://localhost:1", Metrics: metrics})
+	_ = unreachable.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+
+	if len(metrics.observations) != 3 {
+		t.Fatalf("Expected 3 observations, got %d", len(metrics.observations))
+	}
+
+	want := []struct {
+		path       string
+		statusCode int
+		wantErr    bool
+	}{
+		{"/", http.StatusOK, false},
+		{"/404", http.StatusNotFound, true},
+		{"/", 0, true},
+	}
+	for i, w := range want {
+		got := metrics.observations[i]
+		if got.method != http.MethodGet || got.path != w.path || got.statusCode != w.statusCode || (got.err != nil) != w.wantErr {
+			t.Errorf("Observation %d = %+v, want %+v", i, got, w)
+		}
+		if got.duration <= 0 {
+			t.Errorf("Observation %d has non-positive duration", i)
+		}
+	}
+}
+
+type inFlightMetrics struct {
+	recordingMetrics
+
+	current atomic.Int32
+	peak    atomic.Int32
+}
+
+func (m *inFlightMetrics) IncInFlight() {
+	n := m.current.Add(1)
+	for {
+		peak := m.peak.Load()
+		if n <= peak || m.peak.CompareAndSwap(peak, n) {
+			return
+		}
+	}
+}
+
+func (m *inFlightMetrics) DecInFlight() {
+	m.current.Add(-1)
+}
+
+func TestMetrics_InFlight(t *testing.T) {
+	release := make(chan struct{})
+	var arrived sync.WaitGroup
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			arrived.Done()
+			<-release
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer httpServer.Close()
+
+	metrics := &inFlightMetrics{}
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL, Metrics: metrics})
+
+	const concurrency = 3
+	arrived.Add(concurrency)
+	var done sync.WaitGroup
+	for range concurrency {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			_ = client.Do(context.Background(), http.MethodGet, "/slow", nil, nil, nil)
+		}()
+	}
+
+	arrived.Wait()
+	if got := metrics.current.Load(); got != concurrency {
+		t.Errorf("Expected %d requests in flight, got %d", concurrency, got)
+	}
+	close(release)
+	done.Wait()
+
+	var resp map[string]any
+	_ = client.Do(context.Background(), http.MethodGet, "/", nil, nil, &resp)
+	unreachable, _ := rest.NewClient(rest.Config{BaseURL: "http://localhost:1", Metrics: metrics})
+	_ = unreachable.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+
+	if got := metrics.current.Load(); got != 0 {
+		t.Errorf("Expected no requests in flight, got %d", got)
+	}
+	if got := metrics.peak.Load(); got != concurrency {
+		t.Errorf("Expected peak of %d, got %d", concurrency, got)
+	}
+}
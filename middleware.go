@@ -0,0 +1,81 @@
+package restkit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Doer sends an HTTP request and returns its response. *http.Client implements it.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DoerFunc adapts an ordinary function to the Doer interface.
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+// Do calls f(req).
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps a Doer to inspect or modify requests and responses,
+// for example to sign requests right before they are sent.
+type Middleware func(next Doer) Doer
+
+// RequestEditorFn modifies a request after it is built and before it is sent,
+// for example to add dynamic headers.
+type RequestEditorFn func(ctx context.Context, req *http.Request) error
+
+// ResponseValidatorFn inspects a successful response before its body is decoded,
+// for example to enforce a content type. The body must not be consumed.
+type ResponseValidatorFn func(resp *http.Response) error
+
+// doer returns the HTTP client wrapped with the configured middlewares.
+func (c *Client) doer() Doer {
+	var d Doer = c.client
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		d = c.middlewares[i](d)
+	}
+	return d
+}
+
+// ReadRequestBody returns a copy of the request body without consuming it,
+// e.g. to compute a body hash for request signing. It returns nil for requests
+// without a body and ErrBodyNotReplayable when the body cannot be read twice.
+func ReadRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody == nil {
+		return nil, ErrBodyNotReplayable
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get body: %w", err)
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+	return data, nil
+}
+
+// sign calls the SignRequest hook with the request body, buffering a
+// one-shot body so that it can still be sent afterwards.
+func (c *Client) sign(req *http.Request) error {
+	if err := bufferBody(req, 0); err != nil {
+		return err
+	}
+
+	body, err := ReadRequestBody(req)
+	if err != nil {
+		return err
+	}
+
+	return c.signRequest(req, body)
+}
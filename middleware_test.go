@@ -0,0 +1,317 @@
+package restkit_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+func TestMiddlewares(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+		if r.Header.Get("X-Content-Sha256") != hex.EncodeToString(sum[:]) {
+			t.Errorf("Body hash mismatch for body %q", body)
+		}
+		if r.Header.Get("X-Order") != "outer,inner" {
+			t.Errorf("Unexpected middleware order: %q", r.Header.Get("X-Order"))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer httpServer.Close()
+
+	signer := func(next rest.Doer) rest.Doer {
+		return rest.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			body, err := rest.ReadRequestBody(req)
+			if err != nil {
+				return nil, err
+			}
+			sum := sha256.Sum256(body)
+			req.Header.Set("X-Content-Sha256", hex.EncodeToString(sum[:]))
+			return next.Do(req)
+		})
+	}
+	order := func(name string) rest.Middleware {
+		return func(next rest.Doer) rest.Doer {
+			return rest.DoerFunc(func(req *http.Request) (*http.Response, error) {
+				if prev := req.Header.Get("X-Order"); prev != "" {
+					name = prev + "," + name
+				}
+				req.Header.Set("X-Order", name)
+				return next.Do(req)
+			})
+		}
+	}
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL:     httpServer.URL,
+		Middlewares: []rest.Middleware{order("outer"), order("inner"), signer},
+	})
+
+	if err := client.Do(context.Background(), http.MethodPost, "/", nil, map[string]string{"a": "b"}, nil); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+}
+
+func TestReadRequestBody(t *testing.T) {
+	t.Parallel()
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, "/", strings.NewReader("payload"))
+	body, err := rest.ReadRequestBody(req)
+	if err != nil || string(body) != "payload" {
+		t.Fatalf("ReadRequestBody() = %q, %v", body, err)
+	}
+	if remaining, _ := io.ReadAll(req.Body); string(remaining) != "payload" {
+		t.Errorf("Expected request body to remain unconsumed, got %q", remaining)
+	}
+
+	req, _ = http.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+	if body, err := rest.ReadRequestBody(req); body != nil || err != nil {
+		t.Errorf("Expected nil body without error, got %q, %v", body, err)
+	}
+
+	req, _ = http.NewRequestWithContext(context.Background(), http.MethodPost, "/", io.MultiReader(strings.NewReader("x")))
+	if _, err := rest.ReadRequestBody(req); !errors.Is(err, rest.ErrBodyNotReplayable) {
+		t.Errorf("Expected ErrBodyNotReplayable, got %v", err)
+	}
+}
+
+func TestSignRequest(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		want := r.Method + " " + r.URL.Path + " " + r.Header.Get("Content-Type") + " " + string(body)
+		if r.Header.Get("X-Signature") != want {
+			t.Errorf("Expected signature %q, got %q", want, r.Header.Get("X-Signature"))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer httpServer.Close()
+
+	sign := func(req *http.Request, body []byte) error {
+		req.Header.Set("X-Signature", req.Method+" "+req.URL.Path+" "+req.Header.Get("Content-Type")+" "+string(body))
+		return nil
+	}
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL, SignRequest: sign})
+
+	if err := client.Do(context.Background(), http.MethodPost, "/json", nil, map[string]int{"n": 1}, nil); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	stream := io.MultiReader(strings.NewReader("streamed"))
+	if err := client.DoRAW(context.Background(), http.MethodPut, "/raw", nil, stream, nil); err != nil {
+		t.Fatalf("DoRAW() error = %v", err)
+	}
+
+	errSign := errors.New("no key")
+	failing, _ := rest.NewClient(rest.Config{
+		BaseURL:     httpServer.URL,
+		SignRequest: func(*http.Request, []byte) error { return errSign },
+	})
+	err := failing.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	var internalErr *rest.InternalError
+	if !errors.As(err, &internalErr) || internalErr.Op != "sign" || !errors.Is(err, errSign) {
+		t.Errorf("Expected internal error with op sign, got %v", err)
+	}
+}
+
+func TestRequestEditors(t *testing.T) {
+	type tenantKey struct{}
+
+	var requests int
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if got := r.Header.Get("X-Tenant"); got != "acme" {
+			t.Errorf("Expected X-Tenant acme, got %q", got)
+		}
+		if got := r.Header.Get("X-Order"); got != "first,second" {
+			t.Errorf("Expected editors to run in order, got %q", got)
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer httpServer.Close()
+
+	errNoTenant := errors.New("no tenant")
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		RequestEditors: []rest.RequestEditorFn{
+			func(ctx context.Context, req *http.Request) error {
+				tenant, ok := ctx.Value(tenantKey{}).(string)
+				if !ok {
+					return errNoTenant
+				}
+				req.Header.Set("X-Tenant", tenant)
+				req.Header.Set("X-Order", "first")
+				return nil
+			},
+			func(_ context.Context, req *http.Request) error {
+				req.Header.Set("X-Order", req.Header.Get("X-Order")+",second")
+				return nil
+			},
+		},
+	})
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	if err := client.Do(ctx, http.MethodGet, "/", nil, nil, nil); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	if !rest.IsInternalError(err) || !errors.Is(err, errNoTenant) {
+		t.Errorf("Expected internal error from editor, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected the failing call not to be sent, got %d requests", requests)
+	}
+}
+
+func TestResponseValidators(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", r.URL.Query().Get("type"))
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer httpServer.Close()
+
+	errContentType := errors.New("unexpected content type")
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		ResponseValidators: []rest.ResponseValidatorFn{
+			func(resp *http.Response) error {
+				if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+					return errContentType
+				}
+				return nil
+			},
+		},
+	})
+
+	var resp struct {
+		OK bool `json:"ok"`
+	}
+	if err := client.Do(context.Background(), http.MethodGet, "/?type=application/json", nil, nil, &resp); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if !resp.OK {
+		t.Error("Expected the response to be decoded")
+	}
+
+	resp.OK = false
+	err := client.Do(context.Background(), http.MethodGet, "/?type=text/html", nil, nil, &resp)
+	if !rest.IsInternalError(err) || !errors.Is(err, errContentType) {
+		t.Errorf("Expected internal error from validator, got %v", err)
+	}
+	if resp.OK {
+		t.Error("Expected the rejected response not to be decoded")
+	}
+}
+
+func TestResponseErrorCheck(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/denied":
+			_, _ = w.Write([]byte(`{"success": false, "code": 403}`))
+		case "/broken":
+			_, _ = w.Write([]byte(`{"success": false}`))
+		default:
+			_, _ = w.Write([]byte(`{"success": true, "data": "ok"}`))
+		}
+	}))
+	defer httpServer.Close()
+
+	errEnvelope := errors.New("request failed")
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		ResponseErrorCheck: func(status int, body []byte) error {
+			var envelope struct {
+				Success bool `json:"success"`
+				Code    int  `json:"code"`
+			}
+			if err := json.Unmarshal(body, &envelope); err != nil || envelope.Success {
+				return err
+			}
+			if envelope.Code != 0 {
+				return &rest.APIError{StatusCode: envelope.Code}
+			}
+			return errEnvelope
+		},
+	})
+
+	var resp struct {
+		Data string `json:"data"`
+	}
+	if err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, &resp); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if resp.Data != "ok" {
+		t.Errorf("Expected the checked response to be decoded, got %q", resp.Data)
+	}
+
+	err := client.Do(context.Background(), http.MethodGet, "/denied", nil, nil, nil)
+	apiErr, ok := rest.AsAPIError(err)
+	if !ok || !rest.IsForbidden(err) {
+		t.Fatalf("Expected a 403 API error, got %v", err)
+	}
+	if apiErr.URL != httpServer.URL+"/denied" || string(apiErr.Body) != `{"success": false, "code": 403}` || !apiErr.IsJSON() {
+		t.Errorf("Expected the API error to carry the response, got %+v", apiErr)
+	}
+
+	err = client.Do(context.Background(), http.MethodGet, "/broken", nil, nil, nil)
+	if !rest.IsInternalError(err) || !errors.Is(err, errEnvelope) {
+		t.Errorf("Expected internal error from the check, got %v", err)
+	}
+}
+
+func TestOnResponse(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Served-By", "node-1")
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error": "bad"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer httpServer.Close()
+
+	var seen []*http.Response
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		OnResponse: func(resp *http.Response) {
+			seen = append(seen, resp)
+		},
+	})
+
+	var resp struct {
+		OK bool `json:"ok"`
+	}
+	if err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, &resp); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if !resp.OK {
+		t.Error("Expected the body to be decoded after the callback")
+	}
+
+	err := client.Do(context.Background(), http.MethodGet, "/fail", nil, nil, nil)
+	if apiErr, ok := rest.AsAPIError(err); !ok || string(apiErr.Body) != `{"error": "bad"}` {
+		t.Errorf("Expected API error with body, got %v", err)
+	}
+
+	if len(seen) != 2 {
+		t.Fatalf("Expected 2 responses, got %d", len(seen))
+	}
+	if seen[0].Header.Get("X-Served-By") != "node-1" || seen[0].Request.URL.Path != "/" {
+		t.Errorf("Unexpected raw response: %+v", seen[0])
+	}
+	if seen[1].StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected error response to be passed, got %d", seen[1].StatusCode)
+	}
+}
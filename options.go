@@ -0,0 +1,95 @@
+package restkit
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"time"
+)
+
+// Option overrides client settings. Options are applied by NewClient and Clone.
+type Option func(*Client)
+
+// WithHTTPClient replaces the underlying HTTP client. Its redirects are handled
+// according to Config.MaxRedirects and Config.AllowInsecureRedirect as for
+// Config.Client, on a copy that leaves client untouched.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.client = withRedirectPolicy(client, c.maxRedirects, c.allowInsecureRedirect)
+	}
+}
+
+// WithTimeout sets the request timeout on a copy of the underlying HTTP client,
+// leaving the original HTTP client untouched.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		client := *c.client
+		client.Timeout = timeout
+		c.client = &client
+	}
+}
+
+// WithHeaders replaces the default headers sent with every request.
+func WithHeaders(headers http.Header) Option {
+	return func(c *Client) {
+		c.headers = headers.Clone()
+	}
+}
+
+// WithRootCAs sets the certificate authorities used to verify server certificates.
+// It has no effect when the HTTP client uses a transport other than *http.Transport.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return withTLSConfig(func(config *tls.Config) {
+		config.RootCAs = pool
+	})
+}
+
+// WithInsecureSkipVerify disables verification of server certificates.
+// This makes connections vulnerable to man-in-the-middle attacks and must only be
+// used for local development against self-signed certificates, never in production.
+// It has no effect when the HTTP client uses a transport other than *http.Transport.
+func WithInsecureSkipVerify(skip bool) Option {
+	return withTLSConfig(func(config *tls.Config) {
+		config.InsecureSkipVerify = skip //nolint:gosec // explicitly requested by the caller
+	})
+}
+
+// withTLSConfig modifies the TLS config of a copy of the client's transport.
+func withTLSConfig(fn func(config *tls.Config)) Option {
+	return withTransport(func(transport *http.Transport) {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		} else {
+			transport.TLSClientConfig = transport.TLSClientConfig.Clone()
+		}
+		fn(transport.TLSClientConfig)
+	})
+}
+
+// withTransport modifies a copy of the client's transport, leaving the original
+// HTTP client and transport untouched. The client owns the copy, whose idle
+// connections are closed by Close.
+func withTransport(fn func(transport *http.Transport)) Option {
+	return func(c *Client) {
+		var transport *http.Transport
+		switch t := c.client.Transport.(type) {
+		case nil:
+			defaultTransport, ok := http.DefaultTransport.(*http.Transport)
+			if !ok {
+				return
+			}
+			transport = defaultTransport.Clone()
+		case *http.Transport:
+			transport = t.Clone()
+		default:
+			return
+		}
+
+		fn(transport)
+
+		client := *c.client
+		client.Transport = transport
+		c.client = &client
+		c.transport = transport
+	}
+}
@@ -0,0 +1,68 @@
+package restkit_test
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+func TestWithTimeout(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer httpServer.Close()
+
+	httpClient := &http.Client{}
+	base, _ := rest.NewClient(rest.Config{Client: httpClient, BaseURL: httpServer.URL})
+	clone := base.Clone(rest.WithTimeout(50 * time.Millisecond))
+
+	err := clone.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	if !rest.IsInfrastructureError(err) {
+		t.Errorf("Expected infrastructure error on timeout, got %v", err)
+	}
+
+	if httpClient.Timeout != 0 {
+		t.Errorf("Expected original HTTP client to be untouched, got timeout %v", httpClient.Timeout)
+	}
+
+	if err := base.Do(context.Background(), http.MethodGet, "/", nil, nil, nil); err != nil {
+		t.Errorf("Expected base client without timeout to succeed, got %v", err)
+	}
+}
+
+func TestTLSOptions(t *testing.T) {
+	httpServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer httpServer.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(httpServer.Certificate())
+
+	tests := []struct {
+		name    string
+		opts    []rest.Option
+		wantErr bool
+	}{
+		{name: "Untrusted certificate", opts: nil, wantErr: true},
+		{name: "Custom root CA", opts: []rest.Option{rest.WithRootCAs(pool)}, wantErr: false},
+		{name: "Insecure skip verify", opts: []rest.Option{rest.WithInsecureSkipVerify(true)}, wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL}, tt.opts...)
+
+			err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package restkit
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// CollectAll drains seq into a slice.
+// It stops at the first error and returns the items collected so far along with the error.
+func CollectAll[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	var items []T
+	for item, err := range seq {
+		if err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Paginate requests path and follows RFC 8288 `Link` headers with `rel="next"`
+// until no next link is present, calling fn with every decoded page.
+// Context cancellation is checked before each page is requested.
+func Paginate[T any](
+	ctx context.Context,
+	c *Client,
+	path string,
+	headers http.Header,
+	fn func(page T) error,
+) error {
+	next := path
+	for next != "" {
+		if err := ctx.Err(); err != nil {
+			return newInfrastructureError(next, err)
+		}
+
+		var page T
+		resp, err := c.DoWithResponse(ctx, http.MethodGet, next, headers, nil, &page)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(page); err != nil {
+			return err
+		}
+
+		next = ParseLinkHeader(resp.Header)["next"]
+	}
+
+	return nil
+}
+
+// Pages returns an iterator over the pages of a paginated resource, starting
+// at startPath. Pages are requested lazily as the loop advances. After every
+// page, next returns the path of the following page, e.g. from a cursor field
+// or from the `Link` header with ParseLinkHeader, or false on the last page.
+// A failed request or a canceled ctx yields the error and ends the iteration.
+//
+//	for page, err := range rest.Pages(ctx, client, "/items", nextCursor) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func Pages[T any](
+	ctx context.Context,
+	c *Client,
+	startPath string,
+	next func(page T, headers http.Header) (string, bool),
+) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		path := startPath
+		for {
+			var page T
+			if err := ctx.Err(); err != nil {
+				yield(page, newInfrastructureError(path, err))
+				return
+			}
+
+			resp, err := c.DoWithResponse(ctx, http.MethodGet, path, nil, nil, &page)
+			if err != nil {
+				yield(page, err)
+				return
+			}
+			if !yield(page, nil) {
+				return
+			}
+
+			nextPath, ok := next(page, resp.Header)
+			if !ok || nextPath == "" {
+				return
+			}
+			path = nextPath
+		}
+	}
+}
+
+// ParseLinkHeader parses RFC 8288 `Link` headers into a map from relation type to target URL.
+// Links with multiple space-separated relation types are registered under each of them;
+// the first link wins when a relation type is repeated.
+func ParseLinkHeader(h http.Header) map[string]string {
+	links := make(map[string]string)
+	for _, value := range h.Values("Link") {
+		for _, link := range splitOutsideQuotes(value, ',') {
+			target, rels, ok := parseLink(link)
+			if !ok {
+				continue
+			}
+			for _, rel := range rels {
+				if _, exists := links[rel]; !exists {
+					links[rel] = target
+				}
+			}
+		}
+	}
+	return links
+}
+
+// parseLink parses a single `<target>; param=value` link value.
+func parseLink(link string) (string, []string, bool) {
+	link = strings.TrimSpace(link)
+	if !strings.HasPrefix(link, "<") {
+		return "", nil, false
+	}
+	end := strings.IndexByte(link, '>')
+	if end < 0 {
+		return "", nil, false
+	}
+	target := link[1:end]
+
+	var rels []string
+	for _, param := range splitOutsideQuotes(link[end+1:], ';') {
+		name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+		if !found || !strings.EqualFold(strings.TrimSpace(name), "rel") {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		for _, rel := range strings.Fields(value) {
+			rels = append(rels, strings.ToLower(rel))
+		}
+	}
+
+	return target, rels, true
+}
+
+// splitOutsideQuotes splits s on sep, ignoring separators inside quotes or angle brackets.
+func splitOutsideQuotes(s string, sep byte) []string {
+	var (
+		parts    []string
+		start    int
+		inQuotes bool
+		inAngle  bool
+	)
+	for i := range len(s) {
+		switch ch := s[i]; {
+		case ch == '"' && !inAngle:
+			inQuotes = !inQuotes
+		case ch == '<' && !inQuotes:
+			inAngle = true
+		case ch == '>' && !inQuotes:
+			inAngle = false
+		case ch == sep && !inQuotes && !inAngle:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+const (
+	defaultOffsetParam = "offset"
+	defaultLimitParam  = "limit"
+	defaultPageLimit   = 100
+	defaultMaxItems    = 10_000
+)
+
+// OffsetPagination configures offset/limit pagination for CollectPages.
+type OffsetPagination struct {
+	OffsetParam string // Optional offset query parameter name, defaults to `offset`
+	LimitParam  string // Optional limit query parameter name, defaults to `limit`
+	Limit       int    // Optional page size, defaults to 100
+	MaxItems    int    // Optional cap on the number of collected items, defaults to 10000
+}
+
+func (p OffsetPagination) withDefaults() OffsetPagination {
+	if p.OffsetParam == "" {
+		p.OffsetParam = defaultOffsetParam
+	}
+	if p.LimitParam == "" {
+		p.LimitParam = defaultLimitParam
+	}
+	if p.Limit <= 0 {
+		p.Limit = defaultPageLimit
+	}
+	if p.MaxItems <= 0 {
+		p.MaxItems = defaultMaxItems
+	}
+	return p
+}
+
+// CollectPages requests path with increasing offsets and accumulates the items
+// returned by extract until a page holds fewer than Limit items or MaxItems is reached.
+// Query parameters already present in path are preserved.
+func CollectPages[T, P any](
+	ctx context.Context,
+	c *Client,
+	path string,
+	pagination OffsetPagination,
+	extract func(page P) []T,
+) ([]T, error) {
+	pagination = pagination.withDefaults()
+
+	pageURL, err := url.Parse(path)
+	if err != nil {
+		return nil, newInternalError("CollectPages", fmt.Errorf("failed to parse path: %w", err))
+	}
+	query := pageURL.Query()
+	query.Set(pagination.LimitParam, strconv.Itoa(pagination.Limit))
+
+	var items []T
+	for offset := 0; ; offset += pagination.Limit {
+		query.Set(pagination.OffsetParam, strconv.Itoa(offset))
+		pageURL.RawQuery = query.Encode()
+
+		var page P
+		if err := c.Do(ctx, http.MethodGet, pageURL.String(), nil, nil, &page); err != nil {
+			return items, err
+		}
+
+		pageItems := extract(page)
+		items = append(items, pageItems...)
+
+		if len(items) >= pagination.MaxItems {
+			return items[:pagination.MaxItems], nil
+		}
+		if len(pageItems) < pagination.Limit {
+			return items, nil
+		}
+	}
+}
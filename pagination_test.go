@@ -0,0 +1,236 @@
+package restkit_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+var errPage = errors.New("page error")
+
+func seqOf(items []int, failAt int) iter.Seq2[int, error] {
+	return func(yield func(int, error) bool) {
+		for i, item := range items {
+			if i == failAt {
+				yield(0, errPage)
+				return
+			}
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
+
+func TestCollectAll(t *testing.T) {
+	t.Parallel()
+
+	items, err := rest.CollectAll(seqOf([]int{1, 2, 3}, -1))
+	if err != nil {
+		t.Fatalf("CollectAll() error = %v", err)
+	}
+	if !slices.Equal(items, []int{1, 2, 3}) {
+		t.Errorf("CollectAll() = %v, want [1 2 3]", items)
+	}
+
+	items, err = rest.CollectAll(seqOf([]int{1, 2, 3}, 2))
+	if !errors.Is(err, errPage) {
+		t.Errorf("CollectAll() error = %v, want %v", err, errPage)
+	}
+	if !slices.Equal(items, []int{1, 2}) {
+		t.Errorf("CollectAll() = %v, want partial [1 2]", items)
+	}
+}
+
+func TestParseLinkHeader(t *testing.T) {
+	t.Parallel()
+
+	h := http.Header{}
+	h.Add("Link", `<https://api.example.com/items?page=2&q=a,b>; rel="next", <https://api.example.com/items?page=9>; rel="last"; title="Last, page"`)
+	h.Add("Link", `</items?page=1>; title="x;y"; rel="first prev"`)
+
+	links := rest.ParseLinkHeader(h)
+	want := map[string]string{
+		"next":  "https://api.example.com/items?page=2&q=a,b",
+		"last":  "https://api.example.com/items?page=9",
+		"first": "/items?page=1",
+		"prev":  "/items?page=1",
+	}
+	if !maps.Equal(links, want) {
+		t.Errorf("ParseLinkHeader() = %v, want %v", links, want)
+	}
+
+	if links := rest.ParseLinkHeader(http.Header{"Link": []string{"garbage"}}); len(links) != 0 {
+		t.Errorf("Expected no links for malformed header, got %v", links)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	var httpServer *httptest.Server
+	httpServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 2 {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/items?page=%d>; rel="next"`, httpServer.URL, page+1))
+		}
+		_, _ = fmt.Fprintf(w, `[%d, %d]`, page*2, page*2+1)
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	var items []int
+	err := rest.Paginate(context.Background(), client, "/items?page=0", nil, func(page []int) error {
+		items = append(items, page...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Paginate() error = %v", err)
+	}
+	if !slices.Equal(items, []int{0, 1, 2, 3, 4, 5}) {
+		t.Errorf("Paginate() collected %v", items)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pages := 0
+	err = rest.Paginate(ctx, client, "/items?page=0", nil, func([]int) error {
+		pages++
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || pages != 1 {
+		t.Errorf("Expected cancellation after first page, got err=%v pages=%d", err, pages)
+	}
+}
+
+func TestPages(t *testing.T) {
+	type page struct {
+		Items  []int  `json:"items"`
+		Cursor string `json:"cursor"`
+	}
+
+	var requests int
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		cursor, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+		next := ""
+		if cursor < 2 {
+			next = strconv.Itoa(cursor + 1)
+		}
+		_ = json.NewEncoder(w).Encode(page{Items: []int{cursor * 2, cursor*2 + 1}, Cursor: next})
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+	nextCursor := func(p page, _ http.Header) (string, bool) {
+		return "/items?cursor=" + p.Cursor, p.Cursor != ""
+	}
+
+	var items []int
+	for p, err := range rest.Pages(context.Background(), client, "/items", nextCursor) {
+		if err != nil {
+			t.Fatalf("Pages() error = %v", err)
+		}
+		items = append(items, p.Items...)
+	}
+	if !slices.Equal(items, []int{0, 1, 2, 3, 4, 5}) {
+		t.Errorf("Pages() yielded %v", items)
+	}
+
+	requests = 0
+	for range rest.Pages(context.Background(), client, "/items", nextCursor) {
+		break
+	}
+	if requests != 1 {
+		t.Errorf("Expected pages to be requested lazily, got %d requests", requests)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pages := 0
+	var lastErr error
+	for _, err := range rest.Pages(ctx, client, "/items", nextCursor) {
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		pages++
+		cancel()
+	}
+	if !errors.Is(lastErr, context.Canceled) || pages != 1 {
+		t.Errorf("Expected cancellation after first page, got err=%v pages=%d", lastErr, pages)
+	}
+
+	var failed error
+	for _, err := range rest.Pages(context.Background(), client, "/missing", nextCursor) {
+		failed = err
+	}
+	if !rest.IsNotFound(failed) {
+		t.Errorf("Expected the request error to be yielded, got %v", failed)
+	}
+}
+
+func TestCollectPages(t *testing.T) {
+	const total = 25
+
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("filter") != "active" {
+			t.Errorf("Expected existing query parameter to be preserved, got %q", r.URL.RawQuery)
+		}
+		offset, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("take"))
+
+		items := []int{}
+		for i := offset; i < min(offset+limit, total); i++ {
+			items = append(items, i)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"items": items, "total": total})
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	type page struct {
+		Items []int `json:"items"`
+		Total int   `json:"total"`
+	}
+	extract := func(p page) []int { return p.Items }
+
+	items, err := rest.CollectPages(context.Background(), client, "/items?filter=active", rest.OffsetPagination{
+		OffsetParam: "skip",
+		LimitParam:  "take",
+		Limit:       10,
+	}, extract)
+	if err != nil {
+		t.Fatalf("CollectPages() error = %v", err)
+	}
+	if len(items) != total || items[total-1] != total-1 {
+		t.Errorf("Expected %d items, got %v", total, items)
+	}
+
+	items, err = rest.CollectPages(context.Background(), client, "/items?filter=active", rest.OffsetPagination{
+		OffsetParam: "skip",
+		LimitParam:  "take",
+		Limit:       10,
+		MaxItems:    15,
+	}, extract)
+	if err != nil {
+		t.Fatalf("CollectPages() error = %v", err)
+	}
+	if len(items) != 15 {
+		t.Errorf("Expected items to be capped at 15, got %d", len(items))
+	}
+}
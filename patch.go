@@ -0,0 +1,204 @@
+package restkit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Media types of the PATCH document formats.
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+// DoMergePatch sends patch as an RFC 7396 JSON Merge Patch document with
+// `Content-Type: application/merge-patch+json` and decodes the response into response.
+// The document is always encoded as JSON, whatever the codec of the client.
+func (c *Client) DoMergePatch(ctx context.Context, path string, headers http.Header, patch, response any) error {
+	document, err := encodeJSONPayload(patch)
+	if err != nil {
+		return c.failEarly(ctx, http.MethodPatch, path, newInternalError("DoMergePatch", err))
+	}
+
+	return c.Do(ctx, http.MethodPatch, path, patchHeaders(headers, MergePatchContentType), document, response)
+}
+
+// DoJSONPatch sends patch as an RFC 6902 JSON Patch document with
+// `Content-Type: application/json-patch+json` and decodes the response into response.
+// A nil or invalid patch is reported as an InternalError without sending the request.
+func (c *Client) DoJSONPatch(ctx context.Context, path string, headers http.Header, patch *JSONPatch, response any) error {
+	if patch == nil {
+		return c.failEarly(ctx, http.MethodPatch, path, newInternalError("DoJSONPatch", ErrNilPatch))
+	}
+	if err := patch.Err(); err != nil {
+		return c.failEarly(ctx, http.MethodPatch, path, newInternalError("DoJSONPatch", err))
+	}
+
+	document, err := json.Marshal(patch)
+	if err != nil {
+		return c.failEarly(ctx, http.MethodPatch, path, newInternalError("DoJSONPatch", fmt.Errorf("failed to marshal payload: %w", err)))
+	}
+
+	return c.Do(ctx, http.MethodPatch, path, patchHeaders(headers, JSONPatchContentType), json.RawMessage(document), response)
+}
+
+// encodeJSONPayload marshals payload as JSON unless it is already encoded.
+func encodeJSONPayload(payload any) (json.RawMessage, error) {
+	switch p := payload.(type) {
+	case json.RawMessage:
+		return p, nil
+	case []byte:
+		return p, nil
+	case string:
+		return json.RawMessage(p), nil
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return data, nil
+}
+
+// patchHeaders returns a copy of headers with the Content-Type of a patch document.
+func patchHeaders(headers http.Header, contentType string) http.Header {
+	headers = headers.Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+	headers.Set("Content-Type", contentType)
+	return headers
+}
+
+// PatchOperation is a single operation of a JSON Patch document.
+type PatchOperation struct {
+	Op    string // Operation name: add, remove, replace, move, copy or test
+	Path  string // JSON Pointer to the target location
+	From  string // JSON Pointer to the source location of move and copy
+	Value any    // Value of add, replace and test
+}
+
+// MarshalJSON encodes the operation with exactly the members its kind requires.
+func (o PatchOperation) MarshalJSON() ([]byte, error) {
+	doc := map[string]any{"op": o.Op, "path": o.Path}
+	switch o.Op {
+	case "add", "replace", "test":
+		doc["value"] = o.Value
+	case "move", "copy":
+		doc["from"] = o.From
+	}
+
+	return json.Marshal(doc) //nolint:wrapcheck // called by encoding/json
+}
+
+// JSONPatch builds an RFC 6902 JSON Patch document.
+// Paths must be JSON Pointers (RFC 6901); use JSONPointer to encode them from
+// reference tokens. The first invalid path is recorded and reported by Err,
+// MarshalJSON and DoJSONPatch.
+type JSONPatch struct {
+	ops []PatchOperation
+	err error
+}
+
+// NewJSONPatch returns an empty JSON Patch document.
+func NewJSONPatch() *JSONPatch {
+	return &JSONPatch{ops: []PatchOperation{}, err: nil}
+}
+
+// Add appends an add operation.
+func (p *JSONPatch) Add(path string, value any) *JSONPatch {
+	return p.append(PatchOperation{Op: "add", Path: path, From: "", Value: value})
+}
+
+// Remove appends a remove operation.
+func (p *JSONPatch) Remove(path string) *JSONPatch {
+	return p.append(PatchOperation{Op: "remove", Path: path, From: "", Value: nil})
+}
+
+// Replace appends a replace operation.
+func (p *JSONPatch) Replace(path string, value any) *JSONPatch {
+	return p.append(PatchOperation{Op: "replace", Path: path, From: "", Value: value})
+}
+
+// Move appends a move operation.
+func (p *JSONPatch) Move(from, path string) *JSONPatch {
+	return p.append(PatchOperation{Op: "move", Path: path, From: from, Value: nil})
+}
+
+// Copy appends a copy operation.
+func (p *JSONPatch) Copy(from, path string) *JSONPatch {
+	return p.append(PatchOperation{Op: "copy", Path: path, From: from, Value: nil})
+}
+
+// Test appends a test operation.
+func (p *JSONPatch) Test(path string, value any) *JSONPatch {
+	return p.append(PatchOperation{Op: "test", Path: path, From: "", Value: value})
+}
+
+// Operations returns the operations added so far.
+func (p *JSONPatch) Operations() []PatchOperation {
+	return p.ops
+}
+
+// Err returns the first invalid path error, if any.
+func (p *JSONPatch) Err() error {
+	return p.err
+}
+
+// MarshalJSON encodes the patch as an array of operations.
+func (p *JSONPatch) MarshalJSON() ([]byte, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	if p.ops == nil {
+		return []byte("[]"), nil
+	}
+
+	return json.Marshal(p.ops) //nolint:wrapcheck // called by encoding/json
+}
+
+func (p *JSONPatch) append(op PatchOperation) *JSONPatch {
+	if p.err == nil {
+		p.err = validatePointer(op.Path)
+	}
+	if p.err == nil && (op.Op == "move" || op.Op == "copy") {
+		p.err = validatePointer(op.From)
+	}
+
+	p.ops = append(p.ops, op)
+	return p
+}
+
+// JSONPointer encodes reference tokens into an RFC 6901 JSON Pointer,
+// escaping `~` and `/` within the tokens.
+func JSONPointer(tokens ...string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+// validatePointer reports whether pointer is a valid JSON Pointer: empty or starting
+// with `/`, with `~` only used in the escape sequences `~0` and `~1`.
+func validatePointer(pointer string) error {
+	if pointer != "" && pointer[0] != '/' {
+		return fmt.Errorf("%w: %q must start with \"/\"", ErrInvalidPointer, pointer)
+	}
+
+	for i := 0; i < len(pointer); i++ {
+		if pointer[i] != '~' {
+			continue
+		}
+		if i+1 == len(pointer) || (pointer[i+1] != '0' && pointer[i+1] != '1') {
+			return fmt.Errorf("%w: %q has an unescaped \"~\"", ErrInvalidPointer, pointer)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package restkit_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+func TestDoMergePatch(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			t.Errorf("Expected PATCH, got %s", r.Method)
+		}
+		if got := r.Header["Content-Type"]; len(got) != 1 || got[0] != "application/merge-patch+json" {
+			t.Errorf("Expected Content-Type application/merge-patch+json, got %q", got)
+		}
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		Headers: http.Header{"Content-Type": []string{"application/json"}},
+	})
+
+	var resp map[string]any
+	patch := map[string]any{"title": "Hello", "author": nil}
+	if err := client.DoMergePatch(context.Background(), "/docs/1", nil, patch, &resp); err != nil {
+		t.Fatalf("DoMergePatch() error = %v", err)
+	}
+
+	if v, ok := resp["author"]; !ok || v != nil {
+		t.Errorf("Expected null member to be sent, got %v", resp)
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	patch := rest.NewJSONPatch().
+		Test("/version", 3).
+		Add(rest.JSONPointer("tags", "-"), "new").
+		Remove("/draft").
+		Replace(rest.JSONPointer("meta", "a/b~c"), nil).
+		Move("/old", "/new").
+		Copy("/src", "/dst")
+
+	data, err := json.Marshal(patch)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := `[{"op":"test","path":"/version","value":3},` +
+		`{"op":"add","path":"/tags/-","value":"new"},` +
+		`{"op":"remove","path":"/draft"},` +
+		`{"op":"replace","path":"/meta/a~1b~0c","value":null},` +
+		`{"from":"/old","op":"move","path":"/new"},` +
+		`{"from":"/src","op":"copy","path":"/dst"}]`
+	if string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+}
+
+func TestJSONPatch_InvalidPointer(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch *rest.JSONPatch
+	}{
+		{name: "missing slash", patch: rest.NewJSONPatch().Remove("name")},
+		{name: "bad escape", patch: rest.NewJSONPatch().Add("/a~2b", 1)},
+		{name: "trailing tilde", patch: rest.NewJSONPatch().Replace("/a~", 1)},
+		{name: "bad from", patch: rest.NewJSONPatch().Move("from", "/to")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !errors.Is(tt.patch.Err(), rest.ErrInvalidPointer) {
+				t.Errorf("Expected ErrInvalidPointer, got %v", tt.patch.Err())
+			}
+			if _, err := json.Marshal(tt.patch); !errors.Is(err, rest.ErrInvalidPointer) {
+				t.Errorf("Expected Marshal() to fail with ErrInvalidPointer, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDoJSONPatch(t *testing.T) {
+	var requests int
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if got := r.Header.Get("Content-Type"); got != "application/json-patch+json" {
+			t.Errorf("Expected Content-Type application/json-patch+json, got %q", got)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `[{"op":"remove","path":"/draft"}]` {
+			t.Errorf("Unexpected patch document: %s", body)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	if err := client.DoJSONPatch(context.Background(), "/docs/1", nil, rest.NewJSONPatch().Remove("/draft"), nil); err != nil {
+		t.Fatalf("DoJSONPatch() error = %v", err)
+	}
+
+	err := client.DoJSONPatch(context.Background(), "/docs/1", nil, rest.NewJSONPatch().Remove("draft"), nil)
+	if !rest.IsInternalError(err) || !errors.Is(err, rest.ErrInvalidPointer) {
+		t.Errorf("Expected internal error for invalid pointer, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected invalid patch not to be sent, got %d requests", requests)
+	}
+}
+
+func TestDoJSONPatch_Invalid(t *testing.T) {
+	httpServer := setupTestServer(t)
+	defer httpServer.Close()
+
+	var reported []error
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		OnError: func(_ context.Context, _, _ string, err error) {
+			reported = append(reported, err)
+		},
+	})
+
+	tests := []struct {
+		name    string
+		patch   *rest.JSONPatch
+		wantErr error
+	}{
+		{name: "Nil patch", patch: nil, wantErr: rest.ErrNilPatch},
+		{name: "Invalid pointer", patch: rest.NewJSONPatch().Remove("draft"), wantErr: rest.ErrInvalidPointer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reported = nil
+			err := client.DoJSONPatch(context.Background(), "/docs/1", nil, tt.patch, nil)
+
+			var internalErr *rest.InternalError
+			if !errors.As(err, &internalErr) || !errors.Is(err, tt.wantErr) || internalErr.Method != http.MethodPatch {
+				t.Errorf("Expected annotated internal error %v, got %v", tt.wantErr, err)
+			}
+			if len(reported) != 1 || !errors.Is(reported[0], tt.wantErr) {
+				t.Errorf("Expected the error to be reported to OnError, got %v", reported)
+			}
+		})
+	}
+}
+
+func TestPatch_NonJSONCodec(t *testing.T) {
+	var gotBodies []string
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBodies = append(gotBodies, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL, Codec: rest.XMLCodec{}})
+
+	type doc struct {
+		Title string `json:"title"`
+	}
+	if err := client.DoMergePatch(context.Background(), "/docs/1", nil, doc{Title: "Hello"}, nil); err != nil {
+		t.Fatalf("DoMergePatch() error = %v", err)
+	}
+	if err := client.DoJSONPatch(context.Background(), "/docs/1", nil, rest.NewJSONPatch().Remove("/draft"), nil); err != nil {
+		t.Fatalf("DoJSONPatch() error = %v", err)
+	}
+
+	want := []string{`{"title":"Hello"}`, `[{"op":"remove","path":"/draft"}]`}
+	if len(gotBodies) != 2 || gotBodies[0] != want[0] || gotBodies[1] != want[1] {
+		t.Errorf("Expected JSON patch documents %q, got %q", want, gotBodies)
+	}
+}
@@ -0,0 +1,42 @@
+package restkit
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// PollUntil repeatedly GETs path, typically the `Location` of a 202 Accepted
+// response, until done reports completion, and returns the final body.
+// Requests are spaced by interval unless the status resource sends a
+// `Retry-After` header, which takes precedence. Errors returned by done or by
+// the request itself stop polling. Polls always reach the server, bypassing
+// the cache and the request deduplication.
+func (c *Client) PollUntil(
+	ctx context.Context,
+	path string,
+	done func(status int, body []byte) (bool, error),
+	interval time.Duration,
+) ([]byte, error) {
+	pollCtx := withoutCache(ctx)
+	for {
+		var body []byte
+		meta, err := c.DoWithResponse(pollCtx, http.MethodGet, path, nil, nil, &body)
+		if err != nil {
+			return body, err
+		}
+
+		finished, err := done(meta.StatusCode, body)
+		if err != nil || finished {
+			return body, err
+		}
+
+		delay := interval
+		if after, ok := retryAfter(meta.Header, time.Now()); ok {
+			delay = after
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return body, newInfrastructureError(path, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package restkit_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+func TestPollUntil(t *testing.T) {
+	var polls atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if polls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{"state": "running"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"state": "done"}`))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	body, err := client.PollUntil(context.Background(), "/jobs/1", func(status int, body []byte) (bool, error) {
+		var job struct {
+			State string `json:"state"`
+		}
+		if err := json.Unmarshal(body, &job); err != nil {
+			return false, err
+		}
+		return status == http.StatusOK && job.State == "done", nil
+	}, time.Hour)
+	if err != nil {
+		t.Fatalf("PollUntil() error = %v", err)
+	}
+
+	if string(body) != `{"state": "done"}` {
+		t.Errorf("Expected final body, got %s", body)
+	}
+	if polls.Load() != 3 {
+		t.Errorf("Expected 3 polls, got %d", polls.Load())
+	}
+}
+
+func TestPollUntil_Deadline(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.PollUntil(ctx, "/jobs/1", func(int, []byte) (bool, error) {
+		return false, nil
+	}, 10*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestPollUntil_Cache(t *testing.T) {
+	var polls atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if polls.Add(1) < 3 {
+			_, _ = w.Write([]byte(`{"state": "running"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"state": "done"}`))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL:      httpServer.URL,
+		Cache:        rest.NewLRUCache(10),
+		CacheTTL:     time.Hour,
+		Singleflight: true,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	body, err := client.PollUntil(ctx, "/jobs/1", func(_ int, body []byte) (bool, error) {
+		return string(body) == `{"state": "done"}`, nil
+	}, time.Millisecond)
+	if err != nil {
+		t.Fatalf("PollUntil() error = %v", err)
+	}
+
+	if string(body) != `{"state": "done"}` {
+		t.Errorf("Expected final body, got %s", body)
+	}
+	if polls.Load() != 3 {
+		t.Errorf("Expected 3 polls, got %d", polls.Load())
+	}
+}
@@ -0,0 +1,123 @@
+package restkit
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// ProgressFunc reports the number of bytes transferred so far out of total.
+// total is -1 when the size is not known in advance.
+type ProgressFunc func(transferred, total int64)
+
+// uploadProgressKey is the context key of the upload ProgressFunc.
+type uploadProgressKey struct{}
+
+// WithUploadProgress returns a copy of ctx carrying fn, which is called as the
+// request body is consumed by the transport. Every attempt of a retried request
+// reports its progress from zero again.
+func WithUploadProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, uploadProgressKey{}, fn)
+}
+
+// downloadProgressKey is the context key of the download ProgressFunc.
+type downloadProgressKey struct{}
+
+// WithDownloadProgress returns a copy of ctx carrying fn, which is called as the
+// body of a successful response is read, with the response Content-Length as total.
+func WithDownloadProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, downloadProgressKey{}, fn)
+}
+
+// NewProgressReader returns a reader that calls fn with the number of bytes read
+// so far and total every time data is read from r. Use -1 for an unknown total.
+func NewProgressReader(r io.Reader, total int64, fn ProgressFunc) io.Reader {
+	return &progressReader{r: r, total: total, read: 0, fn: fn}
+}
+
+// Download performs a GET request and copies the body of the response into w.
+// When progress is not nil it is called as the body is read, with the response
+// Content-Length as total.
+func (c *Client) Download(
+	ctx context.Context,
+	path string,
+	headers http.Header,
+	w io.Writer,
+	progress ProgressFunc,
+) error {
+	if progress != nil {
+		ctx = WithDownloadProgress(ctx, progress)
+	}
+
+	_, err := c.doRAW(ctx, http.MethodGet, path, headers, nil, w)
+	return err
+}
+
+// uploadProgressFromContext returns the upload ProgressFunc stored in ctx.
+func uploadProgressFromContext(ctx context.Context) ProgressFunc {
+	fn, _ := ctx.Value(uploadProgressKey{}).(ProgressFunc)
+	return fn
+}
+
+// downloadProgressFromContext returns the download ProgressFunc stored in ctx.
+func downloadProgressFromContext(ctx context.Context) ProgressFunc {
+	fn, _ := ctx.Value(downloadProgressKey{}).(ProgressFunc)
+	return fn
+}
+
+// trackUpload wraps the body of req to report its progress to fn.
+// The content length of req is left untouched.
+func trackUpload(req *http.Request, fn ProgressFunc) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+
+	total := req.ContentLength
+	if total <= 0 {
+		total = -1
+	}
+	req.Body = &progressReadCloser{
+		progressReader: progressReader{r: req.Body, total: total, read: 0, fn: fn},
+		closer:         req.Body,
+	}
+}
+
+// trackDownload wraps the body of resp to report its progress to fn.
+func trackDownload(resp *http.Response, fn ProgressFunc) {
+	total := resp.ContentLength
+	if total < 0 {
+		total = -1
+	}
+	resp.Body = &progressReadCloser{
+		progressReader: progressReader{r: resp.Body, total: total, read: 0, fn: fn},
+		closer:         resp.Body,
+	}
+}
+
+// progressReader reports the number of bytes read from r to fn.
+type progressReader struct {
+	r     io.Reader
+	total int64
+	read  int64
+	fn    ProgressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.read += int64(n)
+		p.fn(p.read, p.total)
+	}
+	return n, err //nolint:wrapcheck // transparent wrapper
+}
+
+// progressReadCloser is a progressReader that closes the underlying body.
+type progressReadCloser struct {
+	progressReader
+
+	closer io.Closer
+}
+
+func (p *progressReadCloser) Close() error {
+	return p.closer.Close() //nolint:wrapcheck // transparent wrapper
+}
@@ -0,0 +1,172 @@
+package restkit_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+type progressCall struct {
+	transferred int64
+	total       int64
+}
+
+func TestClient_UploadProgress(t *testing.T) {
+	payload := strings.Repeat("x", 64<<10)
+
+	var failNext atomic.Bool
+	var gotLength int64
+	var gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if failNext.Swap(false) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		gotLength = r.ContentLength
+		gotBody = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		body      func() io.Reader
+		retry     rest.RetryConfig
+		wantTotal int64
+	}{
+		{
+			name:      "Known length with retry",
+			body:      func() io.Reader { return bytes.NewReader([]byte(payload)) },
+			retry:     rest.RetryConfig{MaxAttempts: 2, Backoff: constantBackoff(time.Millisecond)},
+			wantTotal: int64(len(payload)),
+		},
+		{
+			name:      "Streaming",
+			body:      func() io.Reader { return io.MultiReader(strings.NewReader(payload)) },
+			wantTotal: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A retried upload fails once, so that the body is sent twice.
+			failNext.Store(tt.retry.MaxAttempts > 1)
+
+			client, err := rest.NewClient(rest.Config{BaseURL: server.URL, Retry: tt.retry})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			var calls []progressCall
+			ctx := rest.WithUploadProgress(context.Background(), func(transferred, total int64) {
+				calls = append(calls, progressCall{transferred: transferred, total: total})
+			})
+
+			if err := client.DoRAW(ctx, http.MethodPut, "/upload", nil, tt.body(), nil); err != nil {
+				t.Fatalf("DoRAW() error = %v", err)
+			}
+			if gotBody != payload {
+				t.Errorf("Expected body of %d bytes, got %d bytes", len(payload), len(gotBody))
+			}
+			if tt.wantTotal > 0 && gotLength != tt.wantTotal {
+				t.Errorf("Expected Content-Length %d, got %d", tt.wantTotal, gotLength)
+			}
+
+			if len(calls) == 0 {
+				t.Fatal("Expected progress to be reported")
+			}
+			last := calls[len(calls)-1]
+			if last.transferred != int64(len(payload)) || last.total != tt.wantTotal {
+				t.Errorf("Expected last progress %d/%d, got %d/%d", len(payload), tt.wantTotal, last.transferred, last.total)
+			}
+		})
+	}
+}
+
+func TestNewProgressReader(t *testing.T) {
+	var calls []progressCall
+	r := rest.NewProgressReader(strings.NewReader("hello world"), 11, func(transferred, total int64) {
+		calls = append(calls, progressCall{transferred: transferred, total: total})
+	})
+
+	buf := make([]byte, 4)
+	var got []byte
+	for {
+		n, err := r.Read(buf)
+		got = append(got, buf[:n]...)
+		if err != nil {
+			break
+		}
+	}
+
+	if string(got) != "hello world" {
+		t.Errorf("Expected %q, got %q", "hello world", string(got))
+	}
+	want := []progressCall{{4, 11}, {8, 11}, {11, 11}}
+	if len(calls) != len(want) {
+		t.Fatalf("Expected %d progress calls, got %v", len(want), calls)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("Call %d: expected %v, got %v", i, want[i], calls[i])
+		}
+	}
+}
+
+func TestClient_Download(t *testing.T) {
+	payload := strings.Repeat("y", 32<<10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chunked" {
+			w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+		}
+		_, _ = w.Write([]byte(payload))
+	}))
+	defer server.Close()
+
+	client, err := rest.NewClient(rest.Config{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		wantTotal int64
+	}{
+		{name: "Content-Length", path: "/file", wantTotal: int64(len(payload))},
+		{name: "Chunked", path: "/chunked", wantTotal: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []progressCall
+			var buf bytes.Buffer
+			err := client.Download(context.Background(), tt.path, nil, &buf, func(transferred, total int64) {
+				calls = append(calls, progressCall{transferred: transferred, total: total})
+			})
+			if err != nil {
+				t.Fatalf("Download() error = %v", err)
+			}
+			if buf.String() != payload {
+				t.Errorf("Expected body of %d bytes, got %d bytes", len(payload), buf.Len())
+			}
+			if len(calls) == 0 {
+				t.Fatal("Expected progress to be reported")
+			}
+			last := calls[len(calls)-1]
+			if last.transferred != int64(len(payload)) || last.total != tt.wantTotal {
+				t.Errorf("Expected last progress %d/%d, got %d/%d", len(payload), tt.wantTotal, last.transferred, last.total)
+			}
+		})
+	}
+}
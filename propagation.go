@@ -0,0 +1,134 @@
+package restkit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// W3C Trace Context header names.
+const (
+	traceParentHeader = "Traceparent"
+	traceStateHeader  = "Tracestate"
+)
+
+// TraceContext carries W3C Trace Context headers between services.
+type TraceContext struct {
+	TraceParent string // Value of the `traceparent` header
+	TraceState  string // Value of the `tracestate` header, optional
+}
+
+// traceContextKey is the context key of the TraceContext.
+type traceContextKey struct{}
+
+// WithTraceContext returns a copy of ctx carrying tc, to be propagated by clients
+// with PropagateTraceContext enabled.
+func WithTraceContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+// TraceContextFromContext returns the TraceContext stored in ctx.
+func TraceContextFromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok && tc.TraceParent != ""
+}
+
+// ParseTraceContext reads the trace context from the headers of an incoming request.
+// It returns false when the `traceparent` header is absent.
+func ParseTraceContext(h http.Header) (TraceContext, bool) {
+	tc := TraceContext{
+		TraceParent: h.Get(traceParentHeader),
+		TraceState:  h.Get(traceStateHeader),
+	}
+	return tc, tc.TraceParent != ""
+}
+
+// propagateTraceContext sets the trace context headers of req from ctx unless
+// they are already present.
+func propagateTraceContext(ctx context.Context, req *http.Request) {
+	tc, ok := TraceContextFromContext(ctx)
+	if !ok || req.Header.Get(traceParentHeader) != "" {
+		return
+	}
+
+	req.Header.Set(traceParentHeader, tc.TraceParent)
+	if tc.TraceState != "" {
+		req.Header.Set(traceStateHeader, tc.TraceState)
+	}
+}
+
+// correlationIDKey is the context key of the correlation ID.
+type correlationIDKey struct{}
+
+// WithCorrelationID returns a copy of ctx carrying the correlation ID id.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationIDFromContext returns the correlation ID stored in ctx.
+func CorrelationIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(correlationIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// propagateCorrelationID sets the correlation ID header of req from ctx unless it
+// is already present, generating a new ID when generate is true and ctx has none.
+func propagateCorrelationID(ctx context.Context, req *http.Request, header string, generate bool) error {
+	if req.Header.Get(header) != "" {
+		return nil
+	}
+
+	id, ok := CorrelationIDFromContext(ctx)
+	if !ok {
+		if !generate {
+			return nil
+		}
+
+		var err error
+		if id, err = newUUID(); err != nil {
+			return fmt.Errorf("failed to generate correlation ID: %w", err)
+		}
+	}
+
+	req.Header.Set(header, id)
+	return nil
+}
+
+// contextHeadersKey is the context key of the context-scoped headers.
+type contextHeadersKey struct{}
+
+// WithContextHeaders returns a copy of ctx carrying headers that are added to every
+// request made with it. Headers already stored in ctx are kept unless overridden.
+//
+// Headers are applied with the following precedence, highest first:
+//  1. headers passed to the individual call;
+//  2. headers from Config.Headers and the WithHeaders option;
+//  3. headers from the context.
+func WithContextHeaders(ctx context.Context, headers http.Header) context.Context {
+	merged := HeadersFromContext(ctx)
+	for key, values := range headers {
+		merged[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+	}
+	return context.WithValue(ctx, contextHeadersKey{}, merged)
+}
+
+// HeadersFromContext returns a copy of the context-scoped headers stored in ctx.
+// The result is never nil.
+func HeadersFromContext(ctx context.Context) http.Header {
+	headers, _ := ctx.Value(contextHeadersKey{}).(http.Header)
+	if headers == nil {
+		return http.Header{}
+	}
+	return headers.Clone()
+}
+
+// applyContextHeaders adds the context-scoped headers of ctx to header for keys
+// that are not set yet.
+func applyContextHeaders(ctx context.Context, header http.Header) {
+	headers, _ := ctx.Value(contextHeadersKey{}).(http.Header)
+	for key, values := range headers {
+		if _, ok := header[key]; !ok {
+			header[key] = append([]string(nil), values...)
+		}
+	}
+}
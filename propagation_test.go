@@ -0,0 +1,176 @@
+package restkit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+func TestPropagateTraceContext(t *testing.T) {
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	var got http.Header
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer httpServer.Close()
+
+	incoming := http.Header{}
+	incoming.Set("traceparent", traceParent)
+	incoming.Set("tracestate", "vendor=value")
+
+	tc, ok := rest.ParseTraceContext(incoming)
+	if !ok {
+		t.Fatal("Expected trace context in incoming headers")
+	}
+	ctx := rest.WithTraceContext(context.Background(), tc)
+
+	tests := []struct {
+		name    string
+		enabled bool
+		want    string
+	}{
+		{name: "enabled", enabled: true, want: traceParent},
+		{name: "disabled", enabled: false, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := rest.NewClient(rest.Config{
+				BaseURL:               httpServer.URL,
+				PropagateTraceContext: tt.enabled,
+			})
+
+			if err := client.Do(ctx, http.MethodGet, "/", nil, nil, nil); err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			if got.Get("traceparent") != tt.want {
+				t.Errorf("Expected traceparent %q, got %q", tt.want, got.Get("traceparent"))
+			}
+			if tt.enabled && got.Get("tracestate") != "vendor=value" {
+				t.Errorf("Expected tracestate to be propagated, got %q", got.Get("tracestate"))
+			}
+		})
+	}
+}
+
+func TestCorrelationID(t *testing.T) {
+	var got string
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("X-Correlation-Id")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer httpServer.Close()
+
+	tests := []struct {
+		name     string
+		generate bool
+		ctx      context.Context
+		headers  http.Header
+		want     string
+	}{
+		{
+			name: "from context",
+			ctx:  rest.WithCorrelationID(context.Background(), "abc-123"),
+			want: "abc-123",
+		},
+		{
+			name:    "explicit header wins",
+			ctx:     rest.WithCorrelationID(context.Background(), "abc-123"),
+			headers: http.Header{"X-Correlation-Id": []string{"explicit"}},
+			want:    "explicit",
+		},
+		{
+			name: "absent",
+			ctx:  context.Background(),
+			want: "",
+		},
+		{
+			name:     "generated",
+			generate: true,
+			ctx:      context.Background(),
+			want:     "*",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := rest.NewClient(rest.Config{
+				BaseURL:               httpServer.URL,
+				CorrelationIDHeader:   "X-Correlation-Id",
+				GenerateCorrelationID: tt.generate,
+			})
+
+			if err := client.Do(tt.ctx, http.MethodGet, "/", tt.headers, nil, nil); err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+
+			if tt.want == "*" {
+				if len(got) != 36 {
+					t.Errorf("Expected a generated UUID, got %q", got)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Expected correlation ID %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCorrelationIDFromContext(t *testing.T) {
+	if _, ok := rest.CorrelationIDFromContext(context.Background()); ok {
+		t.Error("Expected no correlation ID in empty context")
+	}
+
+	id, ok := rest.CorrelationIDFromContext(rest.WithCorrelationID(context.Background(), "abc"))
+	if !ok || id != "abc" {
+		t.Errorf("Expected correlation ID %q, got %q", "abc", id)
+	}
+}
+
+func TestContextHeaders(t *testing.T) {
+	var got http.Header
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		Headers: http.Header{"X-Config": []string{"config"}, "X-Shared": []string{"config"}},
+	})
+
+	ctx := rest.WithContextHeaders(context.Background(), http.Header{
+		"X-Context": []string{"outer"},
+		"X-Shared":  []string{"context"},
+		"X-Call":    []string{"context"},
+	})
+	ctx = rest.WithContextHeaders(ctx, http.Header{"x-context": []string{"inner"}})
+
+	err := client.Do(ctx, http.MethodGet, "/", http.Header{"X-Call": []string{"call"}}, nil, nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	want := map[string]string{
+		"X-Context": "inner",
+		"X-Shared":  "config",
+		"X-Call":    "call",
+		"X-Config":  "config",
+	}
+	for key, value := range want {
+		if got.Get(key) != value {
+			t.Errorf("Expected %s %q, got %q", key, value, got.Get(key))
+		}
+	}
+
+	if headers := rest.HeadersFromContext(context.Background()); headers == nil || len(headers) != 0 {
+		t.Errorf("Expected empty headers for a plain context, got %v", headers)
+	}
+}
@@ -0,0 +1,57 @@
+//go:build protobuf
+
+package restkit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtobufContentType is the media type of protobuf encoded bodies.
+const ProtobufContentType = "application/x-protobuf"
+
+// DoProto sends msg encoded as protobuf and decodes the response into out.
+// Either message may be nil to send or expect no body.
+// It is only available when building with the `protobuf` build tag.
+func (c *Client) DoProto(
+	ctx context.Context,
+	method, path string,
+	headers http.Header,
+	msg, out proto.Message,
+) error {
+	var reqBody io.Reader
+	if msg != nil {
+		data, err := proto.Marshal(msg)
+		if err != nil {
+			return c.failEarly(ctx, method, path, newInternalError("DoProto", fmt.Errorf("failed to marshal payload: %w", err)))
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	headers = c.mergeHeaders(headers)
+	if headers.Get("Accept") == "" {
+		headers.Set("Accept", ProtobufContentType)
+	}
+	if reqBody != nil && headers.Get("Content-Type") == "" {
+		headers.Set("Content-Type", ProtobufContentType)
+	}
+
+	var body []byte
+	if _, err := c.doRAW(ctx, method, path, headers, reqBody, &body); err != nil {
+		return err
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := proto.Unmarshal(body, out); err != nil {
+		return newInternalError("DoProto", fmt.Errorf("failed to unmarshal response: %w", err))
+	}
+
+	return nil
+}
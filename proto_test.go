@@ -0,0 +1,81 @@
+//go:build protobuf
+
+package restkit_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+func TestDoProto(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != rest.ProtobufContentType || r.Header.Get("Accept") != rest.ProtobufContentType {
+			t.Errorf("Unexpected headers: %v", r.Header)
+		}
+
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("no such message"))
+			return
+		}
+
+		data, _ := io.ReadAll(r.Body)
+		var in wrapperspb.StringValue
+		if err := proto.Unmarshal(data, &in); err != nil {
+			t.Errorf("Unmarshal() error = %v", err)
+		}
+
+		out, _ := proto.Marshal(wrapperspb.String("hello, " + in.GetValue()))
+		w.Header().Set("Content-Type", rest.ProtobufContentType)
+		_, _ = w.Write(out)
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	var out wrapperspb.StringValue
+	if err := client.DoProto(context.Background(), http.MethodPost, "/greet", nil, wrapperspb.String("world"), &out); err != nil {
+		t.Fatalf("DoProto() error = %v", err)
+	}
+	if out.GetValue() != "hello, world" {
+		t.Errorf("Expected %q, got %q", "hello, world", out.GetValue())
+	}
+
+	err := client.DoProto(context.Background(), http.MethodPost, "/missing", nil, wrapperspb.String("world"), &out)
+	if apiErr, ok := rest.AsAPIError(err); !ok || string(apiErr.Body) != "no such message" {
+		t.Errorf("Expected API error with body, got %v", err)
+	}
+}
+
+func TestDoProto_MarshalError(t *testing.T) {
+	httpServer := setupTestServer(t)
+	defer httpServer.Close()
+
+	var reported []error
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		OnError: func(_ context.Context, _, _ string, err error) {
+			reported = append(reported, err)
+		},
+	})
+
+	// Strings must be valid UTF-8 in proto3 messages.
+	err := client.DoProto(context.Background(), http.MethodPost, "/greet", nil, wrapperspb.String("\xff"), nil)
+
+	var internalErr *rest.InternalError
+	if !errors.As(err, &internalErr) || internalErr.Method != http.MethodPost {
+		t.Errorf("Expected annotated internal error, got %v", err)
+	}
+	if len(reported) != 1 || !errors.Is(reported[0], err) {
+		t.Errorf("Expected the error to be reported to OnError, got %v", reported)
+	}
+}
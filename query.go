@@ -0,0 +1,222 @@
+package restkit
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EncodeQuery encodes v as URL query parameters.
+//
+// v may be nil, url.Values or a struct or pointer to a struct. Exported struct
+// fields are encoded under the name from their `url` tag, or the field name when
+// untagged; a tag of "-" skips the field and the `omitempty` option skips zero
+// values. Slices and arrays produce one parameter per element. Nested structs are
+// encoded as `parent[child]`, while embedded structs without a tag are flattened.
+// time.Time values use RFC 3339 and encoding.TextMarshaler implementations their
+// text form.
+func EncodeQuery(v any) (url.Values, error) {
+	values := url.Values{}
+	if v == nil {
+		return values, nil
+	}
+	if query, ok := v.(url.Values); ok {
+		for key, items := range query {
+			values[key] = append([]string(nil), items...)
+		}
+		return values, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return values, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: expected a struct, got %s", ErrInvalidQuery, rv.Type())
+	}
+
+	if err := encodeStruct(values, "", rv); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// DoWithQuery behaves like Do and additionally encodes query with EncodeQuery,
+// adding the parameters to those already present in path.
+func (c *Client) DoWithQuery(
+	ctx context.Context,
+	method, path string,
+	query any,
+	headers http.Header,
+	payload, response any,
+) error {
+	values, err := EncodeQuery(query)
+	if err != nil {
+		return c.failEarly(ctx, method, path, newInternalError("DoWithQuery", err))
+	}
+
+	return c.Do(ctx, method, appendQuery(path, values), headers, payload, response)
+}
+
+// appendQuery adds values to the query string of path.
+func appendQuery(path string, values url.Values) string {
+	if len(values) == 0 {
+		return path
+	}
+
+	path, fragment, hasFragment := strings.Cut(path, "#")
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	path += separator + values.Encode()
+	if hasFragment {
+		path += "#" + fragment
+	}
+	return path
+}
+
+func encodeStruct(values url.Values, prefix string, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("url")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		omitEmpty := strings.Contains(","+opts+",", ",omitempty,")
+
+		fv := rv.Field(i)
+		if field.Anonymous && tag == "" && indirectType(field.Type).Kind() == reflect.Struct && !isScalar(fv) {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if err := encodeStruct(values, prefix, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		if prefix != "" {
+			name = prefix + "[" + name + "]"
+		}
+
+		if omitEmpty && fv.IsZero() {
+			continue
+		}
+		if err := encodeValue(values, name, fv, omitEmpty); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func encodeValue(values url.Values, name string, fv reflect.Value, omitEmpty bool) error {
+	for fv.Kind() == reflect.Pointer || fv.Kind() == reflect.Interface {
+		if fv.IsNil() {
+			if !omitEmpty {
+				values.Add(name, "")
+			}
+			return nil
+		}
+		fv = fv.Elem()
+	}
+
+	switch {
+	case isScalar(fv):
+		s, err := formatScalar(fv)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrInvalidQuery, name, err)
+		}
+		values.Add(name, s)
+	case fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array:
+		if omitEmpty && fv.Len() == 0 {
+			return nil
+		}
+		for i := range fv.Len() {
+			if err := encodeValue(values, name, fv.Index(i), false); err != nil {
+				return err
+			}
+		}
+	case fv.Kind() == reflect.Struct:
+		return encodeStruct(values, name, fv)
+	default:
+		return fmt.Errorf("%w: %s: unsupported type %s", ErrInvalidQuery, name, fv.Type())
+	}
+
+	return nil
+}
+
+// isScalar reports whether fv is encoded as a single parameter value.
+func isScalar(fv reflect.Value) bool {
+	if fv.Type() == reflect.TypeFor[time.Time]() || fv.Type().Implements(reflect.TypeFor[encoding.TextMarshaler]()) {
+		return true
+	}
+
+	switch fv.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func formatScalar(fv reflect.Value) (string, error) {
+	if t, ok := fv.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339), nil
+	}
+	if m, ok := fv.Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal text: %w", err)
+		}
+		return string(text), nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		return fv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(fv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(fv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(fv.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(fv.Float(), 'f', -1, 32), nil
+	default:
+		return strconv.FormatFloat(fv.Float(), 'f', -1, 64), nil
+	}
+}
+
+// indirectType returns the element type of pointer types.
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,141 @@
+package restkit_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+type Paging struct {
+	Page  int `url:"page,omitempty"`
+	Limit int `url:"limit,omitempty"`
+}
+
+type filter struct {
+	Paging
+
+	Query  string    `url:"q"`
+	Tags   []string  `url:"tag,omitempty"`
+	Active *bool     `url:"active,omitempty"`
+	Since  time.Time `url:"since,omitempty"`
+	Addr   net.IP    `url:"addr,omitempty"`
+	Range  struct {
+		Min float64 `url:"min"`
+		Max float64 `url:"max,omitempty"`
+	} `url:"range"`
+	Ignored string `url:"-"`
+	Plain   string
+	hidden  string
+}
+
+func TestEncodeQuery(t *testing.T) {
+	active := true
+	f := filter{
+		Paging: Paging{Page: 2},
+		Query:  "a b",
+		Tags:   []string{"x", "y"},
+		Active: &active,
+		Since:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Addr:   net.ParseIP("10.0.0.1"),
+		Plain:  "p",
+		hidden: "h",
+	}
+	f.Range.Min = 1.5
+	f.Ignored = "ignored"
+
+	tests := []struct {
+		name  string
+		input any
+		want  url.Values
+	}{
+		{name: "nil", input: nil, want: url.Values{}},
+		{name: "values", input: url.Values{"a": {"1"}}, want: url.Values{"a": {"1"}}},
+		{name: "nil pointer", input: (*filter)(nil), want: url.Values{}},
+		{
+			name:  "struct",
+			input: &f,
+			want: url.Values{
+				"page":       {"2"},
+				"q":          {"a b"},
+				"tag":        {"x", "y"},
+				"active":     {"true"},
+				"since":      {"2024-01-02T03:04:05Z"},
+				"addr":       {"10.0.0.1"},
+				"range[min]": {"1.5"},
+				"Plain":      {"p"},
+			},
+		},
+		{
+			name:  "zero values",
+			input: filter{},
+			want:  url.Values{"q": {""}, "range[min]": {"0"}, "Plain": {""}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rest.EncodeQuery(tt.input)
+			if err != nil {
+				t.Fatalf("EncodeQuery() error = %v", err)
+			}
+			if got.Encode() != tt.want.Encode() {
+				t.Errorf("EncodeQuery() = %s, want %s", got.Encode(), tt.want.Encode())
+			}
+		})
+	}
+}
+
+func TestEncodeQuery_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input any
+	}{
+		{name: "not a struct", input: 42},
+		{name: "unsupported field", input: struct {
+			M map[string]string `url:"m"`
+		}{M: map[string]string{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := rest.EncodeQuery(tt.input); !errors.Is(err, rest.ErrInvalidQuery) {
+				t.Errorf("Expected ErrInvalidQuery, got %v", err)
+			}
+		})
+	}
+}
+
+func TestClient_DoWithQuery(t *testing.T) {
+	var got string
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.RawQuery
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	query := struct {
+		Page int      `url:"page"`
+		Tags []string `url:"tag"`
+	}{Page: 3, Tags: []string{"a", "b"}}
+
+	if err := client.DoWithQuery(context.Background(), http.MethodGet, "/items?sort=asc", query, nil, nil, nil); err != nil {
+		t.Fatalf("DoWithQuery() error = %v", err)
+	}
+	if got != "sort=asc&page=3&tag=a&tag=b" {
+		t.Errorf("Unexpected query %q", got)
+	}
+
+	err := client.DoWithQuery(context.Background(), http.MethodGet, "/items", 42, nil, nil, nil)
+	if !rest.IsInternalError(err) {
+		t.Errorf("Expected internal error for invalid query, got %v", err)
+	}
+}
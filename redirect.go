@@ -0,0 +1,64 @@
+package restkit
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// NoRedirects disables following redirects when used as Config.MaxRedirects.
+const NoRedirects = -1
+
+// defaultMaxRedirects matches the limit of the net/http default policy.
+const defaultMaxRedirects = 10
+
+// sensitiveHeaders are removed from requests redirected to another host.
+//
+//nolint:gochecknoglobals // read-only list
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// withRedirectPolicy returns a copy of client following redirects according to
+// redirectPolicy. A client with its own CheckRedirect is returned unchanged when
+// maxRedirects is zero.
+func withRedirectPolicy(client *http.Client, maxRedirects int, allowInsecure bool) *http.Client {
+	if maxRedirects == 0 && client.CheckRedirect != nil {
+		return client
+	}
+
+	policed := *client
+	policed.CheckRedirect = redirectPolicy(maxRedirects, allowInsecure)
+	return &policed
+}
+
+// redirectPolicy returns a CheckRedirect function following at most maxRedirects
+// redirects, or 10 when maxRedirects is zero. Exceeding the limit fails with
+// ErrTooManyRedirects, while NoRedirects returns the first response as is.
+// Sensitive headers are removed when the redirect leaves the origin of the
+// original request, and redirects from https to http fail with
+// ErrInsecureRedirect unless allowInsecure is set.
+func redirectPolicy(maxRedirects int, allowInsecure bool) func(req *http.Request, via []*http.Request) error {
+	if maxRedirects == 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+
+	return func(req *http.Request, via []*http.Request) error {
+		if maxRedirects == NoRedirects {
+			return http.ErrUseLastResponse
+		}
+		if len(via) > maxRedirects {
+			return fmt.Errorf("%w: stopped after %d", ErrTooManyRedirects, maxRedirects)
+		}
+
+		prev := via[len(via)-1]
+		if !allowInsecure && prev.URL.Scheme == "https" && req.URL.Scheme == "http" {
+			return fmt.Errorf("%w: %s to %s", ErrInsecureRedirect, prev.URL.Redacted(), req.URL.Redacted())
+		}
+
+		if req.URL.Scheme != via[0].URL.Scheme || req.URL.Host != via[0].URL.Host {
+			for _, header := range sensitiveHeaders {
+				req.Header.Del(header)
+			}
+		}
+
+		return nil
+	}
+}
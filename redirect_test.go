@@ -0,0 +1,229 @@
+package restkit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+func setupRedirectServer(t *testing.T, target string) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/once":
+			http.Redirect(w, r, "/target", http.StatusFound)
+		case "/twice":
+			http.Redirect(w, r, "/once", http.StatusFound)
+		case "/away":
+			http.Redirect(w, r, target, http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+}
+
+func TestMaxRedirects(t *testing.T) {
+	httpServer := setupRedirectServer(t, "")
+	defer httpServer.Close()
+
+	isSuccess := func(statusCode int) bool { return statusCode < http.StatusMultipleChoices }
+
+	tests := []struct {
+		name         string
+		maxRedirects int
+		path         string
+		wantStatus   int
+		wantErr      error
+	}{
+		{name: "Default policy", maxRedirects: 0, path: "/twice", wantStatus: 0},
+		{name: "Disabled", maxRedirects: rest.NoRedirects, path: "/once", wantStatus: http.StatusFound},
+		{name: "Within limit", maxRedirects: 2, path: "/twice", wantStatus: 0},
+		{name: "Over limit", maxRedirects: 1, path: "/twice", wantErr: rest.ErrTooManyRedirects},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := rest.NewClient(rest.Config{
+				BaseURL:      httpServer.URL,
+				MaxRedirects: tt.maxRedirects,
+				IsSuccess:    isSuccess,
+			})
+
+			err := client.Do(context.Background(), http.MethodGet, tt.path, nil, nil, nil)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Do() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Errorf("Do() error = %v", err)
+				}
+				return
+			}
+
+			apiErr, ok := rest.AsAPIError(err)
+			if !ok || apiErr.StatusCode != tt.wantStatus {
+				t.Errorf("Expected API error with status %d, got %v", tt.wantStatus, err)
+			}
+		})
+	}
+}
+
+func TestMaxRedirects_StripsAuthorizationCrossHost(t *testing.T) {
+	var gotAuth string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer other.Close()
+
+	httpServer := setupRedirectServer(t, other.URL+"/target")
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL, MaxRedirects: 5})
+
+	headers := http.Header{"Authorization": []string{"Bearer secret"}}
+	if err := client.Do(context.Background(), http.MethodGet, "/away", headers, nil, nil); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if gotAuth != "" {
+		t.Errorf("Expected Authorization to be stripped on cross-host redirect, got %q", gotAuth)
+	}
+}
+
+func TestDefaultRedirectPolicy_StripsHeadersCrossHost(t *testing.T) {
+	var gotAuth, gotCookie, gotCustom string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotCookie = r.Header.Get("Cookie")
+		gotCustom = r.Header.Get("X-Trace")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer other.Close()
+
+	httpServer := setupRedirectServer(t, other.URL+"/target")
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	headers := http.Header{
+		"Authorization": []string{"Bearer secret"},
+		"Cookie":        []string{"session=secret"},
+		"X-Trace":       []string{"abc"},
+	}
+	if err := client.Do(context.Background(), http.MethodGet, "/away", headers, nil, nil); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if gotAuth != "" || gotCookie != "" {
+		t.Errorf("Expected credentials to be stripped on cross-host redirect, got %q and %q", gotAuth, gotCookie)
+	}
+	if gotCustom != "abc" {
+		t.Errorf("Expected other headers to be kept, got %q", gotCustom)
+	}
+}
+
+func TestRedirectPolicy_InsecureRedirect(t *testing.T) {
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer plain.Close()
+
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, plain.URL+"/target", http.StatusFound)
+	}))
+	defer secure.Close()
+
+	tests := []struct {
+		name    string
+		allow   bool
+		wantErr bool
+	}{
+		{name: "Refused by default", allow: false, wantErr: true},
+		{name: "Allowed", allow: true, wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := rest.NewClient(rest.Config{
+				Client:                secure.Client(),
+				BaseURL:               secure.URL,
+				AllowInsecureRedirect: tt.allow,
+			})
+
+			err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+			if tt.wantErr {
+				if !errors.Is(err, rest.ErrInsecureRedirect) {
+					t.Errorf("Expected ErrInsecureRedirect, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Do() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestMaxRedirects_NotRetried(t *testing.T) {
+	var requests atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL:      httpServer.URL,
+		MaxRedirects: 1,
+		Retry:        rest.RetryConfig{MaxAttempts: 3, Backoff: constantBackoff(time.Millisecond)},
+	})
+
+	err := client.Do(context.Background(), http.MethodGet, "/loop", nil, nil, nil)
+	if !rest.IsInternalError(err) || !errors.Is(err, rest.ErrTooManyRedirects) {
+		t.Errorf("Expected internal ErrTooManyRedirects error, got %v", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Expected a single attempt following one redirect, got %d requests", got)
+	}
+}
+
+func TestRedirectPolicy_WithHTTPClient(t *testing.T) {
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer plain.Close()
+
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, plain.URL+"/target", http.StatusFound)
+	}))
+	defer secure.Close()
+
+	httpClient := secure.Client()
+	client, _ := rest.NewClient(rest.Config{BaseURL: secure.URL}, rest.WithHTTPClient(httpClient))
+
+	err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	if !errors.Is(err, rest.ErrInsecureRedirect) {
+		t.Errorf("Expected ErrInsecureRedirect, got %v", err)
+	}
+	if httpClient.CheckRedirect != nil {
+		t.Error("Expected the supplied HTTP client to be left untouched")
+	}
+
+	httpServer := setupRedirectServer(t, "")
+	defer httpServer.Close()
+
+	client, _ = rest.NewClient(rest.Config{BaseURL: httpServer.URL, MaxRedirects: 1}, rest.WithHTTPClient(&http.Client{}))
+	err = client.Do(context.Background(), http.MethodGet, "/twice", nil, nil, nil)
+	if !errors.Is(err, rest.ErrTooManyRedirects) {
+		t.Errorf("Expected ErrTooManyRedirects, got %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package restkit
+
+import (
+	"crypto/tls"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Response holds metadata of a completed request.
+type Response struct {
+	StatusCode int         // HTTP status code
+	Header     http.Header // Response headers
+	RateLimit  *RateLimit  // Parsed rate limit headers, nil when the response has none
+
+	// Duration is the wall-clock time of the final attempt, from sending the
+	// request until the response body has been fully processed.
+	Duration time.Duration
+
+	// Attempts is the number of times the request was sent, including retries,
+	// so Attempts-1 retries occurred. It is zero for responses served from the cache.
+	Attempts int
+
+	// FromCache reports whether the body was served from the response cache,
+	// either as a cache hit or after a 304 Not Modified revalidation.
+	FromCache bool
+
+	// URL is the final URL of the request after following redirects, or the
+	// requested URL for cache hits.
+	URL string
+
+	// Proto is the protocol of the response, e.g. "HTTP/1.1" or "HTTP/2.0".
+	// It is empty for cache hits.
+	Proto string
+
+	// TLS is the state of the TLS connection the response was received on, such
+	// as the negotiated version and cipher suite. It is nil for plain HTTP and
+	// for cache hits.
+	TLS *tls.ConnectionState
+}
+
+func newResponse(resp *http.Response) *Response {
+	meta := &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		RateLimit:  nil,
+		Duration:   0,
+		Attempts:   1,
+		FromCache:  false,
+		URL:        "",
+		Proto:      resp.Proto,
+		TLS:        resp.TLS,
+	}
+	if resp.Request != nil {
+		meta.URL = resp.Request.URL.String()
+	}
+	if rateLimit, ok := ParseRateLimit(resp.Header); ok {
+		meta.RateLimit = &rateLimit
+	}
+	return meta
+}
+
+// epochThreshold separates epoch timestamps from delta-seconds in reset headers.
+// Values above roughly one year of seconds are treated as Unix timestamps.
+const epochThreshold = 365 * 24 * 60 * 60
+
+// RateLimit describes the rate limit state reported by the server.
+type RateLimit struct {
+	Limit     int       // Maximum number of requests in the current window
+	Remaining int       // Number of requests remaining in the current window
+	Reset     time.Time // When the current window resets, zero if unknown
+}
+
+// ParseRateLimit reads rate limit information from the `X-RateLimit-*` headers
+// or, when absent, the `RateLimit-*` headers.
+// The reset value may be either a Unix timestamp (GitHub style) or delta-seconds.
+// It returns false if neither limit nor remaining headers are present.
+func ParseRateLimit(h http.Header) (RateLimit, bool) {
+	for _, prefix := range []string{"X-Ratelimit-", "Ratelimit-"} {
+		limit, hasLimit := parseIntHeader(h, prefix+"Limit")
+		remaining, hasRemaining := parseIntHeader(h, prefix+"Remaining")
+		if !hasLimit && !hasRemaining {
+			continue
+		}
+
+		rateLimit := RateLimit{Limit: limit, Remaining: remaining, Reset: time.Time{}}
+		if reset, ok := parseIntHeader(h, prefix+"Reset"); ok {
+			if reset > epochThreshold {
+				rateLimit.Reset = time.Unix(int64(reset), 0)
+			} else {
+				rateLimit.Reset = time.Now().Add(time.Duration(reset) * time.Second)
+			}
+		}
+
+		return rateLimit, true
+	}
+
+	return RateLimit{}, false
+}
+
+func parseIntHeader(h http.Header, key string) (int, bool) {
+	value := strings.TrimSpace(h.Get(key))
+	if value == "" {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// retryAfter parses the `Retry-After` header, given either as delay-seconds or
+// as an HTTP date relative to now.
+func retryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	value := strings.TrimSpace(h.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,201 @@
+package restkit_test
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	t.Parallel()
+
+	epoch := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		headers   map[string]string
+		want      rest.RateLimit
+		wantOK    bool
+		wantDelta time.Duration
+	}{
+		{
+			name:    "No headers",
+			headers: map[string]string{},
+			wantOK:  false,
+		},
+		{
+			name: "GitHub style epoch reset",
+			headers: map[string]string{
+				"X-RateLimit-Limit":     "60",
+				"X-RateLimit-Remaining": "59",
+				"X-RateLimit-Reset":     "1893456000",
+			},
+			want:   rest.RateLimit{Limit: 60, Remaining: 59, Reset: epoch},
+			wantOK: true,
+		},
+		{
+			name: "Delta seconds reset",
+			headers: map[string]string{
+				"RateLimit-Limit":     "100",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "30",
+			},
+			want:      rest.RateLimit{Limit: 100, Remaining: 0},
+			wantOK:    true,
+			wantDelta: 30 * time.Second,
+		},
+		{
+			name: "Invalid values",
+			headers: map[string]string{
+				"X-RateLimit-Limit": "many",
+			},
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := http.Header{}
+			for k, v := range tt.headers {
+				h.Set(k, v)
+			}
+
+			got, ok := rest.ParseRateLimit(h)
+			if ok != tt.wantOK {
+				t.Fatalf("ParseRateLimit() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got.Limit != tt.want.Limit || got.Remaining != tt.want.Remaining {
+				t.Errorf("ParseRateLimit() = %+v, want %+v", got, tt.want)
+			}
+			if tt.wantDelta > 0 {
+				if until := time.Until(got.Reset); until <= 0 || until > tt.wantDelta {
+					t.Errorf("Expected reset within %v, got %v", tt.wantDelta, until)
+				}
+			} else if !got.Reset.Equal(tt.want.Reset) {
+				t.Errorf("ParseRateLimit() reset = %v, want %v", got.Reset, tt.want.Reset)
+			}
+		})
+	}
+}
+
+func TestClient_DoWithResponse(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "10")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		if r.URL.Path == "/limited" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	resp, err := client.DoWithResponse(context.Background(), http.MethodGet, "/", nil, nil, new(map[string]any))
+	if err != nil {
+		t.Fatalf("DoWithResponse() error = %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.RateLimit == nil || resp.RateLimit.Limit != 10 {
+		t.Errorf("Unexpected response metadata: %+v", resp)
+	}
+
+	resp, err = client.DoWithResponse(context.Background(), http.MethodGet, "/limited", nil, nil, nil)
+	if !rest.IsClientError(err) {
+		t.Fatalf("Expected client error, got %v", err)
+	}
+	if resp == nil || resp.RateLimit == nil || resp.RateLimit.Remaining != 0 {
+		t.Errorf("Expected rate limit metadata along with error, got %+v", resp)
+	}
+}
+
+func TestResponse_Duration(t *testing.T) {
+	const delay = 50 * time.Millisecond
+
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		if r.URL.Path == "/error" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	for _, path := range []string{"/", "/error"} {
+		resp, _ := client.DoWithResponse(context.Background(), http.MethodGet, path, nil, nil, new(map[string]any))
+		if resp == nil {
+			t.Fatalf("Expected response metadata for %s", path)
+		}
+		if resp.Duration < delay {
+			t.Errorf("Expected duration of at least %v for %s, got %v", delay, path, resp.Duration)
+		}
+	}
+}
+
+func TestClient_ResponseMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: server.URL, Cache: rest.NewLRUCache(10)})
+
+	meta, err := client.DoWithResponse(context.Background(), http.MethodGet, "/old", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("DoWithResponse() error = %v", err)
+	}
+	if meta.URL != server.URL+"/new" || meta.Proto != "HTTP/1.1" || meta.FromCache || meta.Attempts != 1 {
+		t.Errorf("Unexpected metadata of a sent request: %+v", meta)
+	}
+
+	meta, err = client.DoWithResponse(context.Background(), http.MethodGet, "/old", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("DoWithResponse() error = %v", err)
+	}
+	if meta.URL != server.URL+"/old" || meta.Proto != "" || !meta.FromCache || meta.Attempts != 0 {
+		t.Errorf("Unexpected metadata of a cache hit: %+v", meta)
+	}
+}
+
+func TestClient_ResponseTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: server.URL, Client: server.Client()})
+	meta, err := client.DoWithResponse(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("DoWithResponse() error = %v", err)
+	}
+	if meta.TLS == nil || meta.TLS.Version < tls.VersionTLS12 || meta.TLS.CipherSuite == 0 {
+		t.Errorf("Expected the TLS state of the connection, got %+v", meta.TLS)
+	}
+
+	plain := setupTestServer(t)
+	defer plain.Close()
+
+	client, _ = rest.NewClient(rest.Config{BaseURL: plain.URL})
+	meta, err = client.DoWithResponse(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("DoWithResponse() error = %v", err)
+	}
+	if meta.TLS != nil {
+		t.Errorf("Expected no TLS state for plain HTTP, got %+v", meta.TLS)
+	}
+}
@@ -0,0 +1,172 @@
+// Package resttest provides utilities for testing clients built on restkit
+// without starting an HTTP server.
+package resttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+)
+
+var ErrNoRoute = errors.New("resttest: no route matches request")
+
+// RecordedRequest is a request received by MockTransport.
+type RecordedRequest struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+type route struct {
+	method  string
+	path    string
+	handler func(req *http.Request) (*http.Response, error)
+}
+
+// MockTransport is an http.RoundTripper that serves canned responses matched
+// by method and path and records every request it receives.
+// Use it via Config.Client, e.g. `rest.Config{Client: mock.Client()}`.
+type MockTransport struct {
+	mu       sync.Mutex
+	routes   []route
+	requests []RecordedRequest
+}
+
+// NewMockTransport creates an empty MockTransport.
+func NewMockTransport() *MockTransport {
+	return &MockTransport{
+		mu:       sync.Mutex{},
+		routes:   nil,
+		requests: nil,
+	}
+}
+
+// Client returns an HTTP client using the transport.
+func (m *MockTransport) Client() *http.Client {
+	return &http.Client{Transport: m}
+}
+
+// Handle registers fn to serve requests with the given method and path.
+// Routes are matched in registration order.
+func (m *MockTransport) Handle(method, path string, fn func(req *http.Request) (*http.Response, error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.routes = append(m.routes, route{method: method, path: path, handler: fn})
+}
+
+// Respond registers a canned response for the given method and path.
+func (m *MockTransport) Respond(method, path string, statusCode int, header http.Header, body []byte) {
+	m.Handle(method, path, func(req *http.Request) (*http.Response, error) {
+		return NewResponse(req, statusCode, header, body), nil
+	})
+}
+
+// RespondJSON registers a canned JSON response for the given method and path.
+// It panics if v cannot be marshaled.
+func (m *MockTransport) RespondJSON(method, path string, statusCode int, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("resttest: failed to marshal response: %v", err))
+	}
+
+	header := http.Header{"Content-Type": []string{"application/json"}}
+	m.Respond(method, path, statusCode, header, body)
+}
+
+// RoundTrip records req and serves it from the first matching route.
+func (m *MockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		body = data
+	}
+
+	m.mu.Lock()
+	m.requests = append(m.requests, RecordedRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Header: req.Header.Clone(),
+		Body:   body,
+	})
+	routes := slices.Clone(m.routes)
+	m.mu.Unlock()
+
+	for _, r := range routes {
+		if r.method == req.Method && r.path == req.URL.Path {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			return r.handler(req)
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s %s", ErrNoRoute, req.Method, req.URL.Path)
+}
+
+// Requests returns the requests received so far.
+func (m *MockTransport) Requests() []RecordedRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Clone(m.requests)
+}
+
+// AssertRequest fails the test unless a request with the given method and path
+// was received carrying all values of header and, when body is non-nil, exactly body.
+func (m *MockTransport) AssertRequest(t testing.TB, method, path string, header http.Header, body []byte) {
+	t.Helper()
+
+	for _, req := range m.Requests() {
+		if req.Method != method || req.Path != path {
+			continue
+		}
+		if body != nil && !bytes.Equal(req.Body, body) {
+			continue
+		}
+		if hasHeaders(req.Header, header) {
+			return
+		}
+	}
+
+	t.Errorf("resttest: no %s %s request with headers %v and body %q", method, path, header, body)
+}
+
+func hasHeaders(got, want http.Header) bool {
+	for key, values := range want {
+		for _, value := range values {
+			if !slices.Contains(got.Values(key), value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// NewResponse builds a response to req with the given status, headers and body.
+func NewResponse(req *http.Request, statusCode int, header http.Header, body []byte) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,64 @@
+package resttest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	rest "github.com/capcom6/go-restkit"
+	"github.com/capcom6/go-restkit/resttest"
+)
+
+func TestMockTransport(t *testing.T) {
+	t.Parallel()
+
+	mock := resttest.NewMockTransport()
+	mock.RespondJSON(http.MethodGet, "/users/1", http.StatusOK, map[string]string{"name": "Alice"})
+	mock.Respond(http.MethodPost, "/users", http.StatusConflict, nil, []byte(`{"error": "exists"}`))
+
+	client, _ := rest.NewClient(rest.Config{Client: mock.Client(), BaseURL: "http://api.test"})
+
+	var user struct {
+		Name string `json:"name"`
+	}
+	if err := client.Do(context.Background(), http.MethodGet, "/users/1", nil, nil, &user); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if user.Name != "Alice" {
+		t.Errorf("Unexpected user: %+v", user)
+	}
+
+	headers := http.Header{"X-Request-Id": []string{"42"}}
+	err := client.Do(context.Background(), http.MethodPost, "/users", headers, map[string]string{"name": "Bob"}, nil)
+	if apiErr, ok := rest.AsAPIError(err); !ok || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 API error, got %v", err)
+	}
+
+	mock.AssertRequest(t, http.MethodPost, "/users", http.Header{
+		"X-Request-Id": []string{"42"},
+		"Content-Type": []string{"application/json"},
+	}, []byte(`{"name":"Bob"}`))
+
+	err = client.Do(context.Background(), http.MethodDelete, "/users/1", nil, nil, nil)
+	if !rest.IsInfrastructureError(err) || !errors.Is(err, resttest.ErrNoRoute) {
+		t.Errorf("Expected ErrNoRoute, got %v", err)
+	}
+
+	if got := len(mock.Requests()); got != 3 {
+		t.Errorf("Expected 3 recorded requests, got %d", got)
+	}
+}
+
+func TestRoundTripperFunc(t *testing.T) {
+	t.Parallel()
+
+	transport := rest.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return resttest.NewResponse(req, http.StatusNoContent, nil, nil), nil
+	})
+
+	client, _ := rest.NewClient(rest.Config{Client: &http.Client{Transport: transport}, BaseURL: "http://api.test"})
+	if err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil); err != nil {
+		t.Errorf("Do() error = %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+package resttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// Mode selects whether a Recorder records or replays interactions.
+type Mode int
+
+const (
+	ModeReplay Mode = iota // Serve responses from the fixture file
+	ModeRecord             // Forward requests to the network and write them to the fixture file
+)
+
+const redactedValue = "REDACTED"
+
+// Interaction is a recorded request/response pair.
+type Interaction struct {
+	Request  InteractionRequest  `json:"request"`
+	Response InteractionResponse `json:"response"`
+}
+
+// InteractionRequest is the request part of an Interaction.
+// The body is stored base64-encoded so that binary payloads survive the fixture.
+type InteractionRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Path   string      `json:"path"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+// InteractionResponse is the response part of an Interaction.
+// The body is stored base64-encoded so that binary payloads survive the fixture.
+type InteractionResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+}
+
+// RecorderConfig configures a Recorder.
+type RecorderConfig struct {
+	Path      string            // Fixture file path
+	Mode      Mode              // Record or replay
+	Transport http.RoundTripper // Optional transport used in record mode, defaults to `http.DefaultTransport`
+
+	// MatchBody reports whether a recorded request body matches the actual one in replay mode.
+	// Optional, bodies are ignored by default.
+	MatchBody func(recorded, actual []byte) bool
+
+	// RedactHeaders lists headers whose values are replaced before writing fixtures.
+	// Optional, defaults to Authorization, Proxy-Authorization, Cookie and Set-Cookie.
+	RedactHeaders []string
+}
+
+// Recorder is an http.RoundTripper that records real interactions to a JSON
+// fixture file and replays them deterministically afterwards.
+// Use it via Config.Client, e.g. `rest.Config{Client: recorder.Client()}`.
+type Recorder struct {
+	config RecorderConfig
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewRecorder creates a Recorder. In replay mode the fixture file is loaded immediately.
+func NewRecorder(config RecorderConfig) (*Recorder, error) {
+	if config.Transport == nil {
+		config.Transport = http.DefaultTransport
+	}
+	if config.RedactHeaders == nil {
+		config.RedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+	}
+
+	r := &Recorder{
+		config:       config,
+		mu:           sync.Mutex{},
+		interactions: nil,
+		used:         nil,
+	}
+
+	if config.Mode == ModeReplay {
+		data, err := os.ReadFile(config.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.interactions); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture: %w", err)
+		}
+		r.used = make([]bool, len(r.interactions))
+	}
+
+	return r, nil
+}
+
+// Client returns an HTTP client using the recorder.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip records or replays req depending on the mode.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		body = data
+	}
+
+	if r.config.Mode == ModeRecord {
+		return r.record(req, body)
+	}
+	return r.replay(req, body)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	outgoing := req.Clone(req.Context())
+	outgoing.Body = io.NopCloser(bytes.NewReader(body))
+
+	resp, err := r.config.Transport.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // transport errors are passed through unchanged
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	interaction := Interaction{
+		Request: InteractionRequest{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Path:   req.URL.Path,
+			Header: r.redact(req.Header),
+			Body:   body,
+		},
+		Response: InteractionResponse{
+			StatusCode: resp.StatusCode,
+			Header:     r.redact(resp.Header),
+			Body:       respBody,
+		},
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, interaction)
+	err = r.save()
+	r.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+// save writes all interactions to the fixture file. The caller must hold r.mu.
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+
+	const fileMode = 0o600
+	if err := os.WriteFile(r.config.Path, data, fileMode); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Prefer interactions that were not served yet so repeated requests replay in order.
+	match := -1
+	for i, interaction := range r.interactions {
+		if !r.matches(interaction.Request, req, body) {
+			continue
+		}
+		if !r.used[i] {
+			match = i
+			break
+		}
+		if match < 0 {
+			match = i
+		}
+	}
+	if match < 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoRoute, req.Method, req.URL.Path)
+	}
+	r.used[match] = true
+
+	recorded := r.interactions[match].Response
+	return NewResponse(req, recorded.StatusCode, recorded.Header, recorded.Body), nil
+}
+
+func (r *Recorder) matches(recorded InteractionRequest, req *http.Request, body []byte) bool {
+	if recorded.Method != req.Method || recorded.Path != req.URL.Path {
+		return false
+	}
+	return r.config.MatchBody == nil || r.config.MatchBody(recorded.Body, body)
+}
+
+func (r *Recorder) redact(header http.Header) http.Header {
+	header = header.Clone()
+	for _, key := range r.config.RedactHeaders {
+		if _, ok := header[http.CanonicalHeaderKey(key)]; ok {
+			header.Set(key, redactedValue)
+		}
+	}
+	return header
+}
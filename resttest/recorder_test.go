@@ -0,0 +1,113 @@
+package resttest_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	rest "github.com/capcom6/go-restkit"
+	"github.com/capcom6/go-restkit/resttest"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(r.Method + " " + string(body)))
+	}))
+
+	fixture := filepath.Join(t.TempDir(), "fixture.json")
+
+	recorder, err := resttest.NewRecorder(resttest.RecorderConfig{Path: fixture, Mode: resttest.ModeRecord})
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	client, _ := rest.NewClient(rest.Config{Client: recorder.Client(), BaseURL: httpServer.URL})
+
+	headers := http.Header{"Authorization": []string{"Bearer secret"}}
+	for _, payload := range []string{"a", "b"} {
+		if err := client.Do(context.Background(), http.MethodPost, "/echo", headers, payload, nil); err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+	}
+	httpServer.Close()
+
+	data, _ := os.ReadFile(fixture)
+	if strings.Contains(string(data), "secret") {
+		t.Error("Expected Authorization header to be redacted in fixture")
+	}
+
+	replayer, err := resttest.NewRecorder(resttest.RecorderConfig{
+		Path:      fixture,
+		Mode:      resttest.ModeReplay,
+		MatchBody: bytes.Equal,
+	})
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	client, _ = rest.NewClient(rest.Config{Client: replayer.Client(), BaseURL: httpServer.URL})
+
+	for _, payload := range []string{"b", "a"} {
+		var echo string
+		if err := client.Do(context.Background(), http.MethodPost, "/echo", nil, payload, &echo); err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		if want := "POST " + payload; echo != want {
+			t.Errorf("Expected replayed %q, got %q", want, echo)
+		}
+	}
+
+	if err := client.Do(context.Background(), http.MethodGet, "/missing", nil, nil, nil); !rest.IsInfrastructureError(err) {
+		t.Errorf("Expected error for unrecorded request, got %v", err)
+	}
+}
+
+func TestRecorder_BinaryBody(t *testing.T) {
+	t.Parallel()
+
+	binary := []byte{0x00, 0xff, 0xfe, 0x80, '\n', 0xc3}
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(append(body, binary...))
+	}))
+
+	fixture := filepath.Join(t.TempDir(), "fixture.json")
+	send := func(t *testing.T, client *rest.Client) []byte {
+		t.Helper()
+
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodPut, httpServer.URL+"/blob", bytes.NewReader(binary))
+		var data []byte
+		if err := client.DoRequest(context.Background(), req, &data); err != nil {
+			t.Fatalf("DoRequest() error = %v", err)
+		}
+		return data
+	}
+
+	recorder, _ := resttest.NewRecorder(resttest.RecorderConfig{Path: fixture, Mode: resttest.ModeRecord})
+	client, _ := rest.NewClient(rest.Config{Client: recorder.Client(), BaseURL: httpServer.URL})
+	recorded := send(t, client)
+	httpServer.Close()
+
+	replayer, err := resttest.NewRecorder(resttest.RecorderConfig{
+		Path:      fixture,
+		Mode:      resttest.ModeReplay,
+		MatchBody: bytes.Equal,
+	})
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	client, _ = rest.NewClient(rest.Config{Client: replayer.Client(), BaseURL: httpServer.URL})
+
+	want := append(append([]byte{}, binary...), binary...)
+	if replayed := send(t, client); !bytes.Equal(recorded, want) || !bytes.Equal(replayed, want) {
+		t.Errorf("Expected binary body %v to round-trip, recorded %v, replayed %v", want, recorded, replayed)
+	}
+}
@@ -0,0 +1,276 @@
+package restkit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"time"
+)
+
+//nolint:gochecknoglobals // read-only list
+var defaultRetryMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPut,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+const (
+	defaultBackoffBase = 100 * time.Millisecond
+	defaultBackoffMax  = 10 * time.Second
+)
+
+// RetryConfig controls automatic retries of failed requests.
+// Infrastructure errors and API errors with a retryable status code are retried.
+//
+// To avoid duplicate side effects only idempotent methods (GET, HEAD, PUT, DELETE
+// and OPTIONS) are retried by default. Other methods, such as POST, are returned
+// on the first failure unless they are listed in RetryMethods or the request
+// carries an `Idempotency-Key` header.
+//
+// Three kinds of deadlines apply to a retried request, and the shortest wins:
+//   - the http.Client timeout (see WithTimeout) limits every single attempt;
+//   - MaxElapsedTime limits the whole retry sequence: no new attempt is started
+//     when its backoff delay would end past the budget, and the last error is returned;
+//   - the context deadline limits everything, including backoff delays, and
+//     results in an InfrastructureError wrapping context.DeadlineExceeded.
+//     No retry is made when its backoff delay plus the duration of the previous
+//     attempt would end past the deadline; the last error is returned instead.
+type RetryConfig struct {
+	MaxAttempts      int      // Total number of attempts including the first one, values below 2 disable retries
+	Backoff          Backoff  // Optional delay strategy between attempts, defaults to ExponentialBackoff
+	RetryMethods     []string // Optional methods eligible for retries, replaces the default idempotent set
+	RetryStatusCodes []int    // Optional status codes triggering a retry, defaults to 429 and all 5xx
+
+	// MaxElapsedTime is the optional time budget of all attempts and delays,
+	// measured from the start of the first attempt. Zero means no limit.
+	MaxElapsedTime time.Duration
+
+	// Budget optionally limits retries across all requests of the client.
+	Budget RetryBudget
+}
+
+// retryPolicy is the prepared form of RetryConfig used by the client.
+type retryPolicy struct {
+	maxAttempts int
+	backoff     Backoff
+	methods     []string
+	statusCodes map[int]struct{} // nil means 429 and all 5xx
+	maxElapsed  time.Duration
+	budget      *retryBudget // nil means no budget
+}
+
+func newRetryPolicy(config RetryConfig) retryPolicy {
+	policy := retryPolicy{
+		maxAttempts: config.MaxAttempts,
+		backoff:     config.Backoff,
+		methods:     config.RetryMethods,
+		statusCodes: nil,
+		maxElapsed:  config.MaxElapsedTime,
+		budget:      newRetryBudget(config.Budget),
+	}
+
+	if policy.backoff == nil {
+		policy.backoff = ExponentialBackoff{Base: defaultBackoffBase, Max: defaultBackoffMax}
+	}
+	if policy.methods == nil {
+		policy.methods = defaultRetryMethods
+	}
+	if config.RetryStatusCodes != nil {
+		policy.statusCodes = make(map[int]struct{}, len(config.RetryStatusCodes))
+		for _, code := range config.RetryStatusCodes {
+			policy.statusCodes[code] = struct{}{}
+		}
+	}
+
+	return policy
+}
+
+// retryOverrideKey is the context key of a per-request retry policy.
+type retryOverrideKey struct{}
+
+// WithRetryOverride returns a copy of ctx carrying config, which replaces the
+// client's retry policy for requests made with the returned context. A zero
+// RetryConfig disables retries for those requests.
+// Retries made with the override draw from the client's retry budget unless
+// config sets its own Budget, whose bucket is then shared only by the requests
+// made with the returned context.
+func WithRetryOverride(ctx context.Context, config RetryConfig) context.Context {
+	return context.WithValue(ctx, retryOverrideKey{}, newRetryPolicy(config))
+}
+
+// retryPolicyFor returns the retry policy for requests made with ctx.
+func (c *Client) retryPolicyFor(ctx context.Context) retryPolicy {
+	if policy, ok := ctx.Value(retryOverrideKey{}).(retryPolicy); ok {
+		if policy.budget == nil {
+			policy.budget = c.retry.budget
+		}
+		return policy
+	}
+	return c.retry
+}
+
+func (p retryPolicy) shouldRetry(req *http.Request, err error) bool {
+	if req.Context().Err() != nil || !p.canRetry(req) {
+		return false
+	}
+
+	if IsInfrastructureError(err) {
+		return true
+	}
+
+	apiErr, ok := AsAPIError(err)
+	return ok && p.isRetryableStatus(apiErr.StatusCode)
+}
+
+func (p retryPolicy) isRetryableStatus(statusCode int) bool {
+	if p.statusCodes == nil {
+		return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+	}
+
+	_, ok := p.statusCodes[statusCode]
+	return ok
+}
+
+// canRetry reports whether req may be sent again without risking duplicate side effects.
+func (p retryPolicy) canRetry(req *http.Request) bool {
+	if req.Header.Get(idempotencyKeyHeader) != "" {
+		return true
+	}
+
+	return slices.Contains(p.methods, req.Method)
+}
+
+// Backoff computes the delay before a retry attempt.
+type Backoff interface {
+	// Next returns the delay before retry number attempt (starting at 1).
+	// prev is the previously returned delay, or zero before the first retry.
+	Next(attempt int, prev time.Duration) time.Duration
+}
+
+// BackoffFunc adapts an ordinary function to the Backoff interface.
+type BackoffFunc func(attempt int, prev time.Duration) time.Duration
+
+// Next calls f(attempt, prev).
+func (f BackoffFunc) Next(attempt int, prev time.Duration) time.Duration {
+	return f(attempt, prev)
+}
+
+// ExponentialBackoff doubles the delay on every attempt and applies full jitter.
+type ExponentialBackoff struct {
+	Base time.Duration // Delay before the first retry
+	Max  time.Duration // Upper bound for a single delay
+}
+
+// Next returns a random delay between zero and min(Max, Base*2^(attempt-1)).
+func (b ExponentialBackoff) Next(attempt int, _ time.Duration) time.Duration {
+	limit := b.Base
+	for i := 1; i < attempt && limit < b.Max; i++ {
+		limit *= 2
+	}
+	limit = min(limit, b.Max)
+	if limit <= 0 {
+		return 0
+	}
+
+	//nolint:gosec // jitter does not need a cryptographically secure source
+	return time.Duration(rand.Int64N(int64(limit)) + 1)
+}
+
+// DecorrelatedJitterBackoff picks every delay at random between Base and three
+// times the previous delay. Retries of concurrent callers spread out better than
+// with ExponentialBackoff, as recommended by the AWS architecture blog.
+type DecorrelatedJitterBackoff struct {
+	Base time.Duration // Smallest delay, also the delay the first retry starts from
+	Max  time.Duration // Upper bound for a single delay, zero means no limit
+}
+
+// Next returns a random delay above Base and up to min(Max, prev*3), where prev
+// is at least Base.
+func (b DecorrelatedJitterBackoff) Next(_ int, prev time.Duration) time.Duration {
+	prev = max(prev, b.Base)
+
+	limit := time.Duration(math.MaxInt64)
+	if prev <= limit/3 { //nolint:mnd // growth factor
+		limit = prev * 3 //nolint:mnd // growth factor
+	}
+	if b.Max > 0 {
+		limit = min(limit, b.Max)
+	}
+	if limit <= b.Base {
+		return max(limit, 0)
+	}
+
+	//nolint:gosec // jitter does not need a cryptographically secure source
+	return b.Base + time.Duration(rand.Int64N(int64(limit-b.Base))+1)
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// rewindRequest returns a copy of req with a fresh body suitable for another attempt.
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return next, nil
+	}
+	if req.GetBody == nil {
+		return nil, ErrBodyNotReplayable
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	next.Body = body
+
+	return next, nil
+}
+
+// exceedsBudget reports whether waiting delay after an attempt sequence that
+// began at start would overrun the elapsed time budget.
+func (p retryPolicy) exceedsBudget(start time.Time, delay time.Duration) bool {
+	return p.maxElapsed > 0 && time.Since(start)+delay > p.maxElapsed
+}
+
+// exceedsDeadline reports whether another attempt, started after delay and
+// lasting about as long as the previous one took, would end past the deadline
+// of ctx. Such an attempt would only be canceled, so it is not made at all.
+func exceedsDeadline(ctx context.Context, delay, attempt time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return ok && time.Until(deadline) < delay+attempt
+}
+
+// withAttempts records the number of attempts made in the APIError or
+// InfrastructureError found in err when the request was retried. The error is
+// returned unwrapped, so that type assertions keep working.
+func withAttempts(err error, attempts int) error {
+	if err == nil || attempts < 2 {
+		return err
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		apiErr.Attempts = attempts
+		return err
+	}
+	var infraErr *InfrastructureError
+	if errors.As(err, &infraErr) {
+		infraErr.Attempts = attempts
+	}
+	return err
+}
@@ -0,0 +1,450 @@
+package restkit_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+func constantBackoff(d time.Duration) rest.Backoff {
+	return rest.BackoffFunc(func(int, time.Duration) time.Duration { return d })
+}
+
+func TestRetry_Succeeds(t *testing.T) {
+	var attempts atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := make([]byte, 3)
+		_, _ = r.Body.Read(body)
+		if string(body) != "abc" {
+			t.Errorf("Expected identical body on every attempt, got %q", body)
+		}
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		Retry:   rest.RetryConfig{MaxAttempts: 3, Backoff: constantBackoff(time.Millisecond)},
+	})
+
+	var resp struct {
+		OK bool `json:"ok"`
+	}
+	err := client.DoRAW(context.Background(), http.MethodPut, "/", nil, strings.NewReader("abc"), &resp)
+	if err != nil {
+		t.Fatalf("DoRAW() error = %v", err)
+	}
+	if !resp.OK || attempts.Load() != 3 {
+		t.Errorf("Expected success after 3 attempts, got ok=%v attempts=%d", resp.OK, attempts.Load())
+	}
+}
+
+func TestRetry_ReplaysOneShotBody(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []string
+	)
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer httpServer.Close()
+
+	payload := strings.Repeat("0123456789", 10)
+
+	tests := []struct {
+		name           string
+		maxReplayBytes int64
+		wantAttempts   int
+	}{
+		{name: "Buffered", maxReplayBytes: 0, wantAttempts: 3},
+		{name: "Too large to buffer", maxReplayBytes: 50, wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bodies = nil
+			client, _ := rest.NewClient(rest.Config{
+				BaseURL:        httpServer.URL,
+				MaxReplayBytes: tt.maxReplayBytes,
+				Retry:          rest.RetryConfig{MaxAttempts: 3, Backoff: constantBackoff(time.Millisecond)},
+			})
+
+			// io.MultiReader hides the concrete type so net/http cannot set GetBody itself.
+			body := io.MultiReader(strings.NewReader(payload))
+			_ = client.DoRAW(context.Background(), http.MethodPut, "/", nil, body, nil)
+
+			if len(bodies) != tt.wantAttempts {
+				t.Fatalf("Expected %d attempts, got %d", tt.wantAttempts, len(bodies))
+			}
+			for i, got := range bodies {
+				if got != payload {
+					t.Errorf("Attempt %d sent %q, want %q", i+1, got, payload)
+				}
+			}
+		})
+	}
+}
+
+func TestRetry_GivesUp(t *testing.T) {
+	var attempts atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		Retry:   rest.RetryConfig{MaxAttempts: 3, Backoff: constantBackoff(time.Millisecond)},
+	})
+
+	err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	if !rest.IsClientError(err) {
+		t.Errorf("Expected client error, got %v", err)
+	}
+	if attempts.Load() != 1 {
+		t.Errorf("Expected non-retryable status to be attempted once, got %d", attempts.Load())
+	}
+}
+
+func TestRetry_Attempts(t *testing.T) {
+	var failures atomic.Int32
+	failures.Store(1)
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if failures.Add(-1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		Retry:   rest.RetryConfig{MaxAttempts: 3, Backoff: constantBackoff(time.Millisecond)},
+	})
+
+	meta, err := client.DoWithResponse(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("DoWithResponse() error = %v", err)
+	}
+	if meta.Attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", meta.Attempts)
+	}
+
+	failures.Store(3)
+	meta, err = client.DoWithResponse(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	if !rest.IsServerError(err) {
+		t.Fatalf("Expected server error, got %v", err)
+	}
+	if meta.Attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", meta.Attempts)
+	}
+	if !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Errorf("Expected attempt count in error message, got %q", err.Error())
+	}
+	if apiErr, ok := err.(*rest.APIError); !ok || apiErr.Attempts != 3 { //nolint:errorlint // the error must not be wrapped
+		t.Errorf("Expected an unwrapped *APIError with 3 attempts, got %#v", err)
+	}
+
+	httpServer.Close()
+	_, err = client.DoWithResponse(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	if infraErr, ok := err.(*rest.InfrastructureError); !ok || infraErr.Attempts != 3 { //nolint:errorlint // the error must not be wrapped
+		t.Errorf("Expected an unwrapped *InfrastructureError with 3 attempts, got %#v", err)
+	}
+}
+
+func TestRetry_Methods(t *testing.T) {
+	var attempts atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer httpServer.Close()
+
+	tests := []struct {
+		name         string
+		method       string
+		retryMethods []string
+		wantAttempts int32
+	}{
+		{name: "GET retried by default", method: http.MethodGet, wantAttempts: 3},
+		{name: "DELETE retried by default", method: http.MethodDelete, wantAttempts: 3},
+		{name: "POST not retried by default", method: http.MethodPost, wantAttempts: 1},
+		{name: "PATCH not retried by default", method: http.MethodPatch, wantAttempts: 1},
+		{name: "POST opted in", method: http.MethodPost, retryMethods: []string{http.MethodPost}, wantAttempts: 3},
+		{name: "GET opted out", method: http.MethodGet, retryMethods: []string{http.MethodPost}, wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts.Store(0)
+			client, _ := rest.NewClient(rest.Config{
+				BaseURL: httpServer.URL,
+				Retry: rest.RetryConfig{
+					MaxAttempts:  3,
+					Backoff:      constantBackoff(time.Millisecond),
+					RetryMethods: tt.retryMethods,
+				},
+			})
+
+			_ = client.Do(context.Background(), tt.method, "/", nil, nil, nil)
+			if attempts.Load() != tt.wantAttempts {
+				t.Errorf("Expected %d attempts, got %d", tt.wantAttempts, attempts.Load())
+			}
+		})
+	}
+}
+
+func TestRetry_StatusCodes(t *testing.T) {
+	var attempts atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		code, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		w.WriteHeader(code)
+	}))
+	defer httpServer.Close()
+
+	tests := []struct {
+		name         string
+		statusCodes  []int
+		status       int
+		wantAttempts int32
+	}{
+		{name: "Default 429", status: http.StatusTooManyRequests, wantAttempts: 3},
+		{name: "Default 5xx", status: http.StatusBadGateway, wantAttempts: 3},
+		{name: "Default 408", status: http.StatusRequestTimeout, wantAttempts: 1},
+		{name: "Custom 425", statusCodes: []int{http.StatusTooEarly}, status: http.StatusTooEarly, wantAttempts: 3},
+		{name: "Custom excludes 500", statusCodes: []int{http.StatusServiceUnavailable}, status: http.StatusInternalServerError, wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts.Store(0)
+			client, _ := rest.NewClient(rest.Config{
+				BaseURL: httpServer.URL,
+				Retry: rest.RetryConfig{
+					MaxAttempts:      3,
+					Backoff:          constantBackoff(time.Millisecond),
+					RetryStatusCodes: tt.statusCodes,
+				},
+			})
+
+			_ = client.Do(context.Background(), http.MethodGet, "/"+strconv.Itoa(tt.status), nil, nil, nil)
+			if attempts.Load() != tt.wantAttempts {
+				t.Errorf("Expected %d attempts, got %d", tt.wantAttempts, attempts.Load())
+			}
+		})
+	}
+}
+
+func TestRetry_CancelDuringBackoff(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		Retry:   rest.RetryConfig{MaxAttempts: 3, Backoff: constantBackoff(time.Minute)},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := client.Do(ctx, http.MethodGet, "/", nil, nil, nil)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected prompt return after cancellation, took %v", elapsed)
+	}
+	if !rest.IsInfrastructureError(err) {
+		t.Errorf("Expected infrastructure error, got %v", err)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	t.Parallel()
+
+	backoff := rest.ExponentialBackoff{Base: 10 * time.Millisecond, Max: 50 * time.Millisecond}
+	for attempt := 1; attempt <= 10; attempt++ {
+		limit := min(10*time.Millisecond<<(attempt-1), 50*time.Millisecond)
+		if delay := backoff.Next(attempt, 0); delay <= 0 || delay > limit {
+			t.Errorf("Next(%d) = %v, want (0, %v]", attempt, delay, limit)
+		}
+	}
+}
+
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+	t.Parallel()
+
+	const (
+		base  = 10 * time.Millisecond
+		limit = time.Second
+	)
+	backoff := rest.DecorrelatedJitterBackoff{Base: base, Max: limit}
+
+	var prev time.Duration
+	for attempt := 1; attempt <= 1000; attempt++ {
+		upper := min(max(prev, base)*3, limit)
+		delay := backoff.Next(attempt, prev)
+		if delay <= base || delay > upper {
+			t.Fatalf("Next(%d, %v) = %v, want (%v, %v]", attempt, prev, delay, base, upper)
+		}
+		prev = delay
+	}
+
+	if delay := (rest.DecorrelatedJitterBackoff{Base: base, Max: base / 2}).Next(1, 0); delay != base/2 {
+		t.Errorf("Expected Max below Base to cap the delay, got %v", delay)
+	}
+	if delay := (rest.DecorrelatedJitterBackoff{Base: base, Max: 0}).Next(1, time.Hour); delay <= base || delay > 3*time.Hour {
+		t.Errorf("Expected no limit without Max, got %v", delay)
+	}
+}
+
+func TestRetry_MaxElapsedTime(t *testing.T) {
+	var attempts atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		Retry: rest.RetryConfig{
+			MaxAttempts:    100,
+			Backoff:        constantBackoff(20 * time.Millisecond),
+			MaxElapsedTime: 50 * time.Millisecond,
+		},
+	})
+
+	start := time.Now()
+	err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	elapsed := time.Since(start)
+
+	if !rest.IsServerError(err) {
+		t.Errorf("Expected the last server error, got %v", err)
+	}
+	if elapsed > 150*time.Millisecond {
+		t.Errorf("Expected retries to stop within the budget, took %v", elapsed)
+	}
+	if got := attempts.Load(); got < 2 || got > 3 {
+		t.Errorf("Expected 2-3 attempts within the budget, got %d", got)
+	}
+}
+
+func TestRetry_ContextDeadline(t *testing.T) {
+	var attempts atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		time.Sleep(30 * time.Millisecond)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		Retry:   rest.RetryConfig{MaxAttempts: 3, Backoff: constantBackoff(50 * time.Millisecond)},
+	})
+
+	// After the first attempt, waiting 50ms and another 30ms attempt cannot
+	// finish within the 100ms deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := client.Do(ctx, http.MethodGet, "/", nil, nil, nil)
+	elapsed := time.Since(start)
+
+	if !rest.IsServerError(err) || rest.IsTimeoutError(err) {
+		t.Errorf("Expected the last server error, got %v", err)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("Expected the retry to be skipped, got %d attempts", got)
+	}
+	if elapsed >= 80*time.Millisecond {
+		t.Errorf("Expected to return without sleeping, took %v", elapsed)
+	}
+}
+
+func TestRetry_ContextOverride(t *testing.T) {
+	var attempts atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: httpServer.URL,
+		Retry:   rest.RetryConfig{MaxAttempts: 3, Backoff: constantBackoff(time.Millisecond)},
+	})
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		method       string
+		wantAttempts int32
+	}{
+		{name: "Client policy", ctx: context.Background(), method: http.MethodGet, wantAttempts: 3},
+		{
+			name:         "Disabled",
+			ctx:          rest.WithRetryOverride(context.Background(), rest.RetryConfig{}),
+			method:       http.MethodGet,
+			wantAttempts: 1,
+		},
+		{
+			name: "More attempts",
+			ctx: rest.WithRetryOverride(context.Background(), rest.RetryConfig{
+				MaxAttempts: 5, Backoff: constantBackoff(time.Millisecond),
+			}),
+			method:       http.MethodGet,
+			wantAttempts: 5,
+		},
+		{
+			name: "Non-idempotent method",
+			ctx: rest.WithRetryOverride(context.Background(), rest.RetryConfig{
+				MaxAttempts:  2,
+				Backoff:      constantBackoff(time.Millisecond),
+				RetryMethods: []string{http.MethodPost},
+			}),
+			method:       http.MethodPost,
+			wantAttempts: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts.Store(0)
+
+			err := client.DoRAW(tt.ctx, tt.method, "/", nil, strings.NewReader("abc"), nil)
+			if !rest.IsServerError(err) {
+				t.Fatalf("Expected server error, got %v", err)
+			}
+			if attempts.Load() != tt.wantAttempts {
+				t.Errorf("Expected %d attempts, got %d", tt.wantAttempts, attempts.Load())
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package restkit
+
+// SchemaValidator validates encoded request payloads, typically against a
+// compiled JSON schema. Keeping the schema library behind this interface
+// leaves the choice of implementation, and its dependency, to the caller.
+// An adapter for github.com/santhosh-tekuri/jsonschema/v6 looks like:
+//
+//	schema, err := jsonschema.NewCompiler().Compile("order.schema.json")
+//	...
+//	validator := rest.SchemaValidatorFunc(func(payload []byte) error {
+//		value, err := jsonschema.UnmarshalJSON(bytes.NewReader(payload))
+//		if err != nil {
+//			return err
+//		}
+//		return schema.Validate(value)
+//	})
+type SchemaValidator interface {
+	Validate(payload []byte) error
+}
+
+// SchemaValidatorFunc adapts an ordinary function to the SchemaValidator interface.
+type SchemaValidatorFunc func(payload []byte) error
+
+// Validate calls f(payload).
+func (f SchemaValidatorFunc) Validate(payload []byte) error {
+	return f(payload)
+}
@@ -0,0 +1,53 @@
+package restkit_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+var errMissingName = errors.New("missing property name")
+
+func TestClient_RequestSchema(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL: server.URL,
+		RequestSchema: rest.SchemaValidatorFunc(func(payload []byte) error {
+			var fields map[string]any
+			if err := json.Unmarshal(payload, &fields); err != nil {
+				return err
+			}
+			if _, ok := fields["name"]; !ok {
+				return errMissingName
+			}
+			return nil
+		}),
+	})
+
+	if err := client.Do(context.Background(), http.MethodPost, "/", nil, map[string]string{"name": "widget"}, nil); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	err := client.Do(context.Background(), http.MethodPost, "/", nil, map[string]int{"size": 1}, nil)
+	if !rest.IsInternalError(err) || !errors.Is(err, rest.ErrSchemaViolation) || !errors.Is(err, errMissingName) {
+		t.Errorf("Expected a schema violation, got %v", err)
+	}
+
+	if err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil); err != nil {
+		t.Errorf("Expected requests without payload to skip validation, got %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected the invalid payload not to be sent, got %d requests", requests)
+	}
+}
@@ -0,0 +1,110 @@
+package restkit
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// flightGroup collapses concurrent calls with the same key into a single call.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// flightCall is an in-flight or completed call of a flightGroup.
+type flightCall struct {
+	done     chan struct{}
+	meta     *Response
+	body     []byte
+	err      error
+	canceled bool // the context of the leading caller ended before the call did
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{
+		mu:    sync.Mutex{},
+		calls: make(map[string]*flightCall),
+	}
+}
+
+// do calls fn unless a call for key is already in flight, in which case it
+// waits for that call and returns its results. A waiting caller gives up once
+// its own ctx is done, and makes the call again when it was cut short by the
+// context of the caller that made it, so that one caller's cancellation is
+// never shared with the others.
+func (g *flightGroup) do(
+	ctx context.Context,
+	key string,
+	fn func() (*Response, []byte, error),
+) (*Response, []byte, error) {
+	for {
+		g.mu.Lock()
+		call, ok := g.calls[key]
+		if !ok {
+			break
+		}
+		g.mu.Unlock()
+
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, nil, newInfrastructureError(key, ctx.Err())
+		}
+		if !call.canceled {
+			return call.meta, call.body, call.err
+		}
+	}
+
+	call := &flightCall{done: make(chan struct{}), meta: nil, body: nil, err: nil, canceled: false}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(call.done)
+	}()
+
+	call.meta, call.body, call.err = fn()
+	call.canceled = call.err != nil && ctx.Err() != nil
+	return call.meta, call.body, call.err
+}
+
+// transmitBody sends req and returns the raw response body.
+// Concurrent GET requests for the same URL share a single round trip when
+// request deduplication is enabled.
+func (c *Client) transmitBody(req *http.Request) (*Response, []byte, error) {
+	send := func() (*Response, []byte, error) {
+		var body []byte
+		meta, err := c.transmit(req, &body)
+		// Annotate before the error is shared, so callers only read it.
+		annotateError(err, req.Method, req.URL.String())
+		return meta, body, err
+	}
+
+	if c.flights == nil || req.Method != http.MethodGet {
+		return send()
+	}
+
+	meta, body, err := c.flights.do(req.Context(), req.URL.String(), send)
+	if meta != nil {
+		shared := *meta
+		shared.Header = meta.Header.Clone()
+		meta = &shared
+	}
+
+	return meta, body, err
+}
+
+// sendDeduplicated sends req through the request deduplication and decodes
+// the shared body into response.
+func (c *Client) sendDeduplicated(req *http.Request, response any) (*Response, error) {
+	meta, body, err := c.transmitBody(req)
+	if err != nil {
+		return meta, err
+	}
+
+	return meta, c.decodeCached(body, meta.Header.Get("Content-Type"), response)
+}
@@ -0,0 +1,129 @@
+package restkit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+func TestSingleflight(t *testing.T) {
+	var requests atomic.Int32
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"tags": ["a", "b"]}`))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL:      httpServer.URL,
+		Singleflight: true,
+	})
+
+	type result struct {
+		Tags []string `json:"tags"`
+	}
+
+	const callers = 10
+	results := make([]result, callers)
+
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, &results[i]); err != nil {
+				t.Errorf("Do() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected concurrent requests to collapse into 1, got %d", got)
+	}
+
+	results[0].Tags[0] = "changed"
+	for i, res := range results[1:] {
+		if len(res.Tags) != 2 || res.Tags[0] != "a" {
+			t.Errorf("Expected caller %d to get its own copy, got %v", i+1, res.Tags)
+		}
+	}
+}
+
+func TestSingleflight_Cancellation(t *testing.T) {
+	var requests atomic.Int32
+	arrived := make(chan struct{}, 1)
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		select {
+		case arrived <- struct{}{}:
+		default:
+		}
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL, Singleflight: true})
+
+	t.Run("Follower deadline", func(t *testing.T) {
+		leader := make(chan error, 1)
+		go func() {
+			leader <- client.Do(context.Background(), http.MethodGet, "/deadline", nil, nil, nil)
+		}()
+		<-arrived
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		err := client.Do(ctx, http.MethodGet, "/deadline", nil, nil, nil)
+		if !rest.IsInfrastructureError(err) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the follower's deadline error, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 80*time.Millisecond {
+			t.Errorf("Expected the follower to stop waiting at its deadline, waited %v", elapsed)
+		}
+		if err := <-leader; err != nil {
+			t.Errorf("Leader Do() error = %v", err)
+		}
+	})
+
+	t.Run("Leader canceled", func(t *testing.T) {
+		requests.Store(0)
+		ctx, cancel := context.WithCancel(context.Background())
+		leader := make(chan error, 1)
+		go func() {
+			leader <- client.Do(ctx, http.MethodGet, "/canceled", nil, nil, nil)
+		}()
+		<-arrived
+
+		follower := make(chan error, 1)
+		go func() {
+			follower <- client.Do(context.Background(), http.MethodGet, "/canceled", nil, nil, nil)
+		}()
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+
+		if err := <-leader; !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected the leader to be canceled, got %v", err)
+		}
+		if err := <-follower; err != nil {
+			t.Errorf("Expected the follower to send the request again, got %v", err)
+		}
+		if got := requests.Load(); got != 2 {
+			t.Errorf("Expected 2 requests, got %d", got)
+		}
+	})
+}
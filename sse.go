@@ -0,0 +1,137 @@
+package restkit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultSSERetry is the reconnection delay used until the server sends a `retry:` field.
+const defaultSSERetry = 3 * time.Second
+
+// Event is a single Server-Sent Event.
+type Event struct {
+	ID    string        // Last event ID, carried over from previous events when not set
+	Type  string        // Event type, defaults to "message"
+	Data  string        // Event payload; multiple data lines are joined with "\n"
+	Retry time.Duration // Reconnection delay announced by the server, zero if none
+}
+
+// Subscribe requests path as a `text/event-stream` and calls handler for every
+// event received. It returns when ctx is canceled, the server closes the stream,
+// or handler returns an error.
+// On infrastructure errors and 5xx responses it reconnects after the delay from the
+// last `retry:` field (3 seconds by default), sending the `Last-Event-ID` header.
+func (c *Client) Subscribe(ctx context.Context, path string, headers http.Header, handler func(Event) error) error {
+	lastID := ""
+	delay := defaultSSERetry
+
+	for {
+		err := c.subscribeOnce(ctx, path, headers, &lastID, &delay, handler)
+		if err == nil || ctx.Err() != nil || !isTransientStreamError(err) {
+			return err
+		}
+
+		if sleepErr := sleepContext(ctx, delay); sleepErr != nil {
+			return newInfrastructureError(path, sleepErr)
+		}
+	}
+}
+
+// subscribeOnce consumes a single connection of an event stream.
+func (c *Client) subscribeOnce(
+	ctx context.Context,
+	path string,
+	headers http.Header,
+	lastID *string,
+	delay *time.Duration,
+	handler func(Event) error,
+) error {
+	ctx, end, err := c.life.begin(withStreaming(ctx))
+	if err != nil {
+		return c.failEarly(ctx, http.MethodGet, path, newInternalError("Subscribe", err))
+	}
+	defer end()
+
+	h := headers.Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+	h.Set("Accept", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	if *lastID != "" {
+		h.Set("Last-Event-ID", *lastID)
+	}
+
+	resp, err := c.openStream(ctx, http.MethodGet, path, h, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	req := resp.Request
+
+	reader := bufio.NewReader(resp.Body)
+	event := Event{ID: *lastID, Type: "", Data: "", Retry: 0}
+	var data strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if ctx.Err() != nil {
+				return newInfrastructureError(req.URL.String(), ctx.Err())
+			}
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return newInfrastructureError(req.URL.String(), fmt.Errorf("failed to read event stream: %w", err))
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if data.Len() > 0 {
+				event.Data = strings.TrimSuffix(data.String(), "\n")
+				if event.Type == "" {
+					event.Type = "message"
+				}
+				if err := handler(event); err != nil {
+					return err
+				}
+			}
+			data.Reset()
+			event = Event{ID: *lastID, Type: "", Data: "", Retry: 0}
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "":
+			// Comment line.
+		case "event":
+			event.Type = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				*lastID = value
+				event.ID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				*delay = time.Duration(ms) * time.Millisecond
+				event.Retry = *delay
+			}
+		}
+	}
+}
+
+// isTransientStreamError reports whether a stream may be resumed after err.
+func isTransientStreamError(err error) bool {
+	return IsInfrastructureError(err) || IsServerError(err)
+}
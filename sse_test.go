@@ -0,0 +1,106 @@
+package restkit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+func TestSubscribe(t *testing.T) {
+	var (
+		connections atomic.Int32
+		lastEventID atomic.Value
+	)
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "text/event-stream" {
+			t.Errorf("Expected Accept text/event-stream, got %q", r.Header.Get("Accept"))
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		if connections.Add(1) == 1 {
+			_, _ = w.Write([]byte(": welcome\nretry: 10\nid: 1\ndata: first\ndata: line\n\n"))
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+
+		lastEventID.Store(r.Header.Get("Last-Event-ID"))
+		_, _ = w.Write([]byte("event: update\r\ndata: second\r\n\r\n"))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	var events []rest.Event
+	err := client.Subscribe(context.Background(), "/events", nil, func(e rest.Event) error {
+		events = append(events, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	want := []rest.Event{
+		{ID: "1", Type: "message", Data: "first\nline", Retry: 10 * time.Millisecond},
+		{ID: "1", Type: "update", Data: "second"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("Expected %d events, got %+v", len(want), events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("Expected event %+v, got %+v", want[i], events[i])
+		}
+	}
+
+	if connections.Load() != 2 {
+		t.Errorf("Expected a reconnect, got %d connections", connections.Load())
+	}
+	if got := lastEventID.Load(); got != "1" {
+		t.Errorf("Expected Last-Event-ID %q on reconnect, got %v", "1", got)
+	}
+}
+
+func TestSubscribe_HandlerError(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("data: a\n\ndata: b\n\n"))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	errStop := errors.New("stop")
+	calls := 0
+	err := client.Subscribe(context.Background(), "/", nil, func(rest.Event) error {
+		calls++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || calls != 1 {
+		t.Errorf("Expected handler error after 1 event, got %v after %d", err, calls)
+	}
+}
+
+func TestSubscribe_Cancel(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("data: a\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := client.Subscribe(ctx, "/", nil, func(rest.Event) error {
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
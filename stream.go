@@ -0,0 +1,165 @@
+package restkit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DoStreamingJSON performs a request whose response is a stream of concatenated JSON values.
+// onChunk is invoked for every value as soon as it arrives, including the last one,
+// which is additionally decoded into T once the stream ends.
+// Cancelling ctx stops reading the stream.
+func DoStreamingJSON[T any](
+	ctx context.Context,
+	c *Client,
+	method, path string,
+	onChunk func(json.RawMessage) error,
+) (T, error) {
+	var result T
+
+	ctx, end, err := c.life.begin(withStreaming(ctx))
+	if err != nil {
+		return result, c.failEarly(ctx, method, path, newInternalError("DoStreamingJSON", err))
+	}
+	defer end()
+
+	headers := http.Header{}
+	headers.Set("Accept", "application/json")
+
+	resp, err := c.openStream(ctx, method, path, headers, nil)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+	req := resp.Request
+
+	var last json.RawMessage
+	decoder := c.newDecoder(resp.Body)
+	for {
+		var chunk json.RawMessage
+		if err := decoder.Decode(&chunk); err != nil {
+			if ctx.Err() != nil {
+				return result, newInfrastructureError(req.URL.String(), ctx.Err())
+			}
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return result, newInternalError("DoStreamingJSON", fmt.Errorf("failed to decode chunk: %w", err))
+		}
+
+		if err := onChunk(chunk); err != nil {
+			return result, err
+		}
+		last = chunk
+	}
+
+	if last == nil {
+		return result, newInternalError("DoStreamingJSON", fmt.Errorf("empty stream: %w", io.ErrUnexpectedEOF))
+	}
+
+	if err := c.unmarshal(last, &result); err != nil {
+		return result, newInternalError("DoStreamingJSON", fmt.Errorf("failed to decode result: %w", err))
+	}
+
+	return result, nil
+}
+
+// DoStreamJSON performs a request whose response is a stream of JSON values,
+// such as newline-delimited JSON, and calls handler for every decoded value.
+// The payload, if any, is encoded as in Do. Decoding stops at the end of the
+// stream or at the first handler error, which is returned as is.
+// Cancelling ctx stops reading the stream.
+func DoStreamJSON[T any](
+	ctx context.Context,
+	c *Client,
+	method, path string,
+	headers http.Header,
+	payload any,
+	handler func(T) error,
+) error {
+	ctx, end, err := c.life.begin(withStreaming(ctx))
+	if err != nil {
+		return c.failEarly(ctx, method, path, newInternalError("DoStreamJSON", err))
+	}
+	defer end()
+
+	var reqBody io.Reader
+	if payload != nil {
+		jsonBytes, err := c.encodePayload(payload)
+		if err != nil {
+			return c.failEarly(ctx, method, path, newInternalError("DoStreamJSON", fmt.Errorf("failed to marshal payload: %w", err)))
+		}
+		reqBody = bytes.NewReader(jsonBytes)
+	}
+
+	headers = c.mergeHeaders(headers)
+	if headers.Get("Accept") == "" {
+		headers.Set("Accept", "application/x-ndjson")
+	}
+	if reqBody != nil && headers.Get("Content-Type") == "" {
+		headers.Set("Content-Type", c.codec.ContentType())
+	}
+
+	resp, err := c.openStream(ctx, method, path, headers, reqBody)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	req := resp.Request
+
+	decoder := c.newDecoder(resp.Body)
+	for {
+		var item T
+		if err := decoder.Decode(&item); err != nil {
+			if ctx.Err() != nil {
+				return newInfrastructureError(req.URL.String(), ctx.Err())
+			}
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return newInternalError("DoStreamJSON", fmt.Errorf("failed to decode item: %w", err))
+		}
+
+		if err := handler(item); err != nil {
+			return err
+		}
+	}
+}
+
+// openStream sends a request whose response body is read as a stream. The
+// request is prepared like those of Do, with the request editors, trace context,
+// correlation ID and idempotency key, but it is neither retried nor cached.
+// A failure to open the stream is annotated and reported to the OnError hook.
+// The caller must close the body of the returned response.
+func (c *Client) openStream(
+	ctx context.Context,
+	method, path string,
+	headers http.Header,
+	body io.Reader,
+) (*http.Response, error) {
+	req, err := c.newRequest(ctx, method, path, headers, body)
+	target := path
+	var resp *http.Response
+	if err == nil {
+		target = req.URL.String()
+		err = c.prepare(ctx, req)
+	}
+	if err == nil {
+		resp, err = c.exchange(req)
+	}
+	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		annotateError(err, method, target)
+		c.reportError(ctx, method, target, err)
+		return nil, err
+	}
+
+	return resp, nil
+}
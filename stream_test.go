@@ -0,0 +1,239 @@
+package restkit_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+func TestDoStreamingJSON(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		flusher, _ := w.(http.Flusher)
+		for _, chunk := range []string{`{"progress": 50}`, `{"progress": 100}`, `{"id": "123", "state": "Done"}`} {
+			_, _ = w.Write([]byte(chunk + "\n"))
+			flusher.Flush()
+		}
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	var chunks []json.RawMessage
+	result, err := rest.DoStreamingJSON[struct {
+		ID    string `json:"id"`
+		State string `json:"state"`
+	}](context.Background(), client, http.MethodGet, "/", func(chunk json.RawMessage) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("DoStreamingJSON() error = %v", err)
+	}
+	if len(chunks) != 3 {
+		t.Errorf("Expected 3 chunks, got %d", len(chunks))
+	}
+	if result.ID != "123" || result.State != "Done" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+}
+
+func TestDoStreamingJSON_Cancel(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, _ := w.(http.Flusher)
+		_, _ = w.Write([]byte(`{"progress": 1}`))
+		flusher.Flush()
+		<-r.Context().Done()
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Now()
+	_, err := rest.DoStreamingJSON[map[string]any](ctx, client, http.MethodGet, "/", func(json.RawMessage) error {
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected prompt return after cancellation, took %v", elapsed)
+	}
+}
+
+func TestDoStreamJSON(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var filter struct {
+			Kind string `json:"kind"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&filter)
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for _, id := range []string{"1", "2", "3"} {
+			_, _ = w.Write([]byte(`{"id": "` + id + `", "kind": "` + filter.Kind + `"}` + "\n"))
+		}
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	type record struct {
+		ID   string `json:"id"`
+		Kind string `json:"kind"`
+	}
+
+	var records []record
+	err := rest.DoStreamJSON(context.Background(), client, http.MethodPost, "/export", nil,
+		map[string]string{"kind": "user"},
+		func(r record) error {
+			records = append(records, r)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("DoStreamJSON() error = %v", err)
+	}
+	if len(records) != 3 || records[2].ID != "3" || records[2].Kind != "user" {
+		t.Errorf("Unexpected records: %+v", records)
+	}
+
+	errStop := errors.New("stop")
+	calls := 0
+	err = rest.DoStreamJSON(context.Background(), client, http.MethodPost, "/export", nil, nil,
+		func(record) error {
+			calls++
+			return errStop
+		})
+	if !errors.Is(err, errStop) || calls != 1 {
+		t.Errorf("Expected handler error after 1 record, got %v after %d", err, calls)
+	}
+}
+
+func TestDoStreamJSON_DecodeError(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("{\"id\": 1}\n{broken\n"))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL})
+
+	calls := 0
+	err := rest.DoStreamJSON(context.Background(), client, http.MethodGet, "/", nil, nil,
+		func(map[string]any) error {
+			calls++
+			return nil
+		})
+	if !rest.IsInternalError(err) || calls != 1 {
+		t.Errorf("Expected internal error after 1 record, got %v after %d", err, calls)
+	}
+}
+
+func TestStreaming_RequestPreparation(t *testing.T) {
+	var received http.Header
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		switch {
+		case r.URL.Path == "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Header.Get("Accept") == "text/event-stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: {}\n\n"))
+		default:
+			_, _ = w.Write([]byte("{}\n"))
+		}
+	}))
+	defer httpServer.Close()
+
+	var reported []string
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL:               httpServer.URL,
+		IdempotencyKey:        true,
+		PropagateTraceContext: true,
+		CorrelationIDHeader:   "X-Correlation-Id",
+		RequestEditors: []rest.RequestEditorFn{func(_ context.Context, req *http.Request) error {
+			req.Header.Set("X-Edited", "yes")
+			return nil
+		}},
+		OnError: func(_ context.Context, method, url string, _ error) {
+			reported = append(reported, method+" "+url)
+		},
+	})
+
+	calls := map[string]func(ctx context.Context, path string) error{
+		"DoStreamingJSON": func(ctx context.Context, path string) error {
+			_, err := rest.DoStreamingJSON[struct{}](ctx, client, http.MethodPost, path, func(json.RawMessage) error { return nil })
+			return err
+		},
+		"DoStreamJSON": func(ctx context.Context, path string) error {
+			return rest.DoStreamJSON(ctx, client, http.MethodPost, path, nil, nil, func(struct{}) error { return nil })
+		},
+		"Subscribe": func(ctx context.Context, path string) error {
+			return client.Subscribe(ctx, path, nil, func(rest.Event) error { return nil })
+		},
+	}
+
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx := rest.WithTraceContext(context.Background(), rest.TraceContext{TraceParent: traceParent})
+	ctx = rest.WithCorrelationID(ctx, "corr-1")
+
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			if err := call(ctx, "/stream"); err != nil {
+				t.Fatalf("%s() error = %v", name, err)
+			}
+
+			want := map[string]string{"X-Edited": "yes", "Traceparent": traceParent, "X-Correlation-Id": "corr-1"}
+			for header, value := range want {
+				if got := received.Get(header); got != value {
+					t.Errorf("Expected %s header %q, got %q", header, value, got)
+				}
+			}
+			if name != "Subscribe" && received.Get("Idempotency-Key") == "" {
+				t.Error("Expected an Idempotency-Key header")
+			}
+
+			reported = nil
+			if err := call(ctx, "/missing"); !rest.IsAPIError(err) {
+				t.Fatalf("%s() error = %v, want an API error", name, err)
+			}
+			if len(reported) != 1 || !strings.HasSuffix(reported[0], "/missing") {
+				t.Errorf("Expected the failure to be reported to OnError, got %v", reported)
+			}
+		})
+	}
+}
+
+func TestDoStreamJSON_Codec(t *testing.T) {
+	var gotContentType, gotBody string
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotContentType, gotBody = r.Header.Get("Content-Type"), string(body)
+		_, _ = w.Write([]byte("{}\n"))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL, Codec: rest.XMLCodec{}})
+
+	type filter struct {
+		XMLName struct{} `xml:"filter"`
+		Kind    string   `xml:"kind"`
+	}
+	err := rest.DoStreamJSON(context.Background(), client, http.MethodPost, "/export", nil,
+		filter{Kind: "user"}, func(struct{}) error { return nil })
+	if err != nil {
+		t.Fatalf("DoStreamJSON() error = %v", err)
+	}
+	if gotContentType != rest.XMLContentType || gotBody != `<filter><kind>user</kind></filter>` {
+		t.Errorf("Expected the payload to be encoded by the codec, got %q: %s", gotContentType, gotBody)
+	}
+}
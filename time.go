@@ -0,0 +1,79 @@
+package restkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// TimeLayout supplies the layout of a Time, in the format of time.Parse.
+type TimeLayout interface {
+	Layout() string
+}
+
+// DateTimeLayout is the `2006-01-02 15:04:05` layout of time.DateTime.
+type DateTimeLayout struct{}
+
+// Layout returns time.DateTime.
+func (DateTimeLayout) Layout() string { return time.DateTime }
+
+// Time is a time.Time encoded in JSON, CSV and query strings with the layout of L
+// instead of RFC 3339, for APIs using another timestamp format. Decoding also accepts RFC 3339 and
+// a null leaves the zero time. Define a TimeLayout for layouts not provided here:
+//
+//	type usDate struct{}
+//
+//	func (usDate) Layout() string { return "01/02/2006" }
+//
+//	type Order struct {
+//		CreatedAt rest.Time[rest.DateTimeLayout] `json:"created_at"`
+//		DueOn     rest.Time[usDate]              `json:"due_on"`
+//	}
+type Time[L TimeLayout] struct {
+	time.Time
+}
+
+// MarshalText encodes the time in the layout of L, for CSV and query encoding.
+func (t Time[L]) MarshalText() ([]byte, error) {
+	var layout L
+	return []byte(t.Format(layout.Layout())), nil
+}
+
+// UnmarshalText decodes a time in the layout of L or in RFC 3339.
+func (t *Time[L]) UnmarshalText(text []byte) error {
+	var layout L
+	parsed, err := time.Parse(layout.Layout(), string(text))
+	if err != nil {
+		var rfcErr error
+		if parsed, rfcErr = time.Parse(time.RFC3339, string(text)); rfcErr != nil {
+			return fmt.Errorf("failed to parse time: %w", err)
+		}
+	}
+
+	t.Time = parsed
+	return nil
+}
+
+// MarshalJSON encodes the time as a string in the layout of L.
+func (t Time[L]) MarshalJSON() ([]byte, error) {
+	text, err := t.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text)) //nolint:wrapcheck // transparent wrapper
+}
+
+// UnmarshalJSON decodes a string in the layout of L or in RFC 3339.
+func (t *Time[L]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("time must be a string: %w", err)
+	}
+
+	return t.UnmarshalText([]byte(value))
+}
@@ -0,0 +1,101 @@
+package restkit_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+type usDateLayout struct{}
+
+func (usDateLayout) Layout() string { return "01/02/2006" }
+
+func TestTime_UnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "Custom layout", data: `"2024-03-01 12:30:45"`, want: time.Date(2024, 3, 1, 12, 30, 45, 0, time.UTC)},
+		{name: "RFC 3339", data: `"2024-03-01T12:30:45Z"`, want: time.Date(2024, 3, 1, 12, 30, 45, 0, time.UTC)},
+		{name: "RFC 3339 offset", data: `"2024-03-01T12:30:45+02:00"`, want: time.Date(2024, 3, 1, 10, 30, 45, 0, time.UTC)},
+		{name: "Null", data: `null`, want: time.Time{}},
+		{name: "Invalid", data: `"yesterday"`, wantErr: true},
+		{name: "Not a string", data: `1709296245`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got struct {
+				At rest.Time[rest.DateTimeLayout] `json:"at"`
+			}
+			err := json.Unmarshal([]byte(`{"at":`+tt.data+`}`), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.At.Equal(tt.want) {
+				t.Errorf("Unmarshal() = %v, want %v", got.At.Time, tt.want)
+			}
+		})
+	}
+}
+
+func TestTime_MarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	value := struct {
+		At rest.Time[rest.DateTimeLayout] `json:"at"`
+		On rest.Time[usDateLayout]        `json:"on"`
+	}{}
+	value.At.Time = time.Date(2024, 3, 1, 12, 30, 45, 0, time.UTC)
+	value.On.Time = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := `{"at":"2024-03-01 12:30:45","on":"03/01/2024"}`; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	var decoded struct {
+		On rest.Time[usDateLayout] `json:"on"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil || !decoded.On.Equal(value.On.Time) {
+		t.Errorf("Unmarshal() = %v, %v, want %v", decoded.On.Time, err, value.On.Time)
+	}
+}
+
+func TestTime_Text(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	query, err := rest.EncodeQuery(struct {
+		On rest.Time[usDateLayout] `url:"on"`
+	}{On: rest.Time[usDateLayout]{Time: at}})
+	if err != nil {
+		t.Fatalf("EncodeQuery() error = %v", err)
+	}
+	if got := query.Get("on"); got != "03/01/2024" {
+		t.Errorf("EncodeQuery() on = %q, want %q", got, "03/01/2024")
+	}
+
+	var rows []struct {
+		On rest.Time[usDateLayout] `csv:"on"`
+	}
+	if err := (rest.CSVDecoder{}).Decode(strings.NewReader("on\n03/01/2024\n"), &rows); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(rows) != 1 || !rows[0].On.Equal(at) {
+		t.Errorf("Decode() = %+v, want %v", rows, at)
+	}
+}
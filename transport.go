@@ -0,0 +1,124 @@
+package restkit
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// unixBaseURL is the placeholder base URL used for clients talking to a Unix
+// domain socket. The host is never resolved; only the path reaches the server.
+const unixBaseURL = "http://localhost"
+
+// Dialer settings of `http.DefaultTransport`, kept when only some are overridden.
+const (
+	defaultDialTimeout = 30 * time.Second
+	defaultKeepAlive   = 30 * time.Second
+)
+
+// RoundTripperFunc adapts an ordinary function to the http.RoundTripper interface.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// newTransport builds a transport from the connection settings of config.
+// It returns nil when config has no transport settings.
+func newTransport(config Config) *http.Transport {
+	if config.MaxIdleConns == 0 &&
+		config.MaxIdleConnsPerHost == 0 &&
+		config.MaxConnsPerHost == 0 &&
+		config.IdleConnTimeout == 0 &&
+		config.DialTimeout == 0 &&
+		config.TLSHandshakeTimeout == 0 &&
+		config.ResponseHeaderTimeout == 0 &&
+		config.MaxResponseHeaderBytes == 0 &&
+		!config.ForceHTTP2 &&
+		config.UnixSocket == "" &&
+		config.Proxy == nil {
+		return nil
+	}
+
+	defaultTransport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return nil
+	}
+	transport := defaultTransport.Clone()
+
+	if config.MaxIdleConns != 0 {
+		transport.MaxIdleConns = config.MaxIdleConns
+	}
+	if config.MaxIdleConnsPerHost != 0 {
+		transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	}
+	if config.MaxConnsPerHost != 0 {
+		transport.MaxConnsPerHost = config.MaxConnsPerHost
+	}
+	if config.IdleConnTimeout != 0 {
+		transport.IdleConnTimeout = config.IdleConnTimeout
+	}
+
+	dialer := &net.Dialer{Timeout: defaultDialTimeout, KeepAlive: defaultKeepAlive}
+	if config.DialTimeout != 0 {
+		dialer.Timeout = config.DialTimeout
+		transport.DialContext = dialer.DialContext
+	}
+	if config.TLSHandshakeTimeout != 0 {
+		transport.TLSHandshakeTimeout = config.TLSHandshakeTimeout
+	}
+	if config.ResponseHeaderTimeout != 0 {
+		transport.ResponseHeaderTimeout = config.ResponseHeaderTimeout
+	}
+	if config.MaxResponseHeaderBytes != 0 {
+		transport.MaxResponseHeaderBytes = config.MaxResponseHeaderBytes
+	}
+
+	if config.ForceHTTP2 {
+		protocols := new(http.Protocols)
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+		transport.Protocols = protocols
+	}
+
+	if config.Proxy != nil {
+		transport.Proxy = http.ProxyURL(config.Proxy)
+	}
+
+	if config.UnixSocket != "" {
+		socket := config.UnixSocket
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+	}
+
+	return transport
+}
+
+// resolveUnixSocket turns a `unix://` base URL into a socket path plus the
+// placeholder base URL, and supplies the placeholder when only UnixSocket is set.
+func resolveUnixSocket(config *Config) error {
+	if u, err := url.Parse(config.BaseURL); err == nil && u.Scheme == "unix" {
+		socket := u.Path
+		if socket == "" {
+			socket = u.Opaque
+		}
+		if socket == "" {
+			return fmt.Errorf("%w: unix base URL must include a socket path (got %q)", ErrInvalidConfig, config.BaseURL)
+		}
+		config.UnixSocket = socket
+		config.BaseURL = unixBaseURL
+		return nil
+	}
+
+	if config.UnixSocket != "" && config.BaseURL == "" {
+		config.BaseURL = unixBaseURL
+	}
+
+	return nil
+}
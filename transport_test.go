@@ -0,0 +1,346 @@
+package restkit_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rest "github.com/capcom6/go-restkit"
+)
+
+// setupCountingServer returns a server that counts new TCP connections.
+func setupCountingServer(t testing.TB) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var conns atomic.Int32
+	httpServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	httpServer.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	httpServer.Start()
+
+	return httpServer, &conns
+}
+
+func TestConnectionPool(t *testing.T) {
+	httpServer, conns := setupCountingServer(t)
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		BaseURL:             httpServer.URL,
+		MaxIdleConnsPerHost: 8,
+		MaxConnsPerHost:     8,
+		IdleConnTimeout:     time.Minute,
+	})
+
+	for range 5 {
+		done := make(chan struct{})
+		for range 8 {
+			go func() {
+				defer func() { done <- struct{}{} }()
+				if err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil); err != nil {
+					t.Errorf("Do() error = %v", err)
+				}
+			}()
+		}
+		for range 8 {
+			<-done
+		}
+	}
+
+	if got := conns.Load(); got > 8 {
+		t.Errorf("Expected at most 8 connections to be opened and reused, got %d", got)
+	}
+}
+
+func BenchmarkConnectionPool(b *testing.B) {
+	configs := map[string]rest.Config{
+		"Default": {},
+		"Tuned":   {MaxIdleConns: 100, MaxIdleConnsPerHost: 100},
+	}
+
+	for name, config := range configs {
+		b.Run(name, func(b *testing.B) {
+			httpServer, conns := setupCountingServer(b)
+			defer httpServer.Close()
+
+			config.BaseURL = httpServer.URL
+			if config.MaxIdleConnsPerHost == 0 {
+				// Use a dedicated default transport so idle connections are not shared between runs.
+				config.Client = &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+			}
+			client, _ := rest.NewClient(config)
+
+			b.SetParallelism(16)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_ = client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+				}
+			})
+			b.ReportMetric(float64(conns.Load()), "conns")
+		})
+	}
+}
+
+func TestForceHTTP2(t *testing.T) {
+	httpServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	}))
+	httpServer.Config.Protocols = new(http.Protocols)
+	httpServer.Config.Protocols.SetHTTP1(true)
+	httpServer.Config.Protocols.SetUnencryptedHTTP2(true)
+	httpServer.Start()
+	defer httpServer.Close()
+
+	tests := []struct {
+		name       string
+		forceHTTP2 bool
+		want       string
+	}{
+		{name: "Default", forceHTTP2: false, want: "HTTP/1.1"},
+		{name: "Forced h2c", forceHTTP2: true, want: "HTTP/2.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := rest.NewClient(rest.Config{BaseURL: httpServer.URL, ForceHTTP2: tt.forceHTTP2})
+
+			var proto string
+			if err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, &proto); err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			if proto != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, proto)
+			}
+		})
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "api.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets are not supported: %v", err)
+	}
+
+	httpServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	httpServer.Listener = listener
+	httpServer.Start()
+	defer httpServer.Close()
+
+	tests := []struct {
+		name   string
+		config rest.Config
+		want   string
+	}{
+		{
+			name:   "unix base URL",
+			config: rest.Config{BaseURL: "unix://" + socket},
+			want:   "/v1/status",
+		},
+		{
+			name:   "socket with placeholder host",
+			config: rest.Config{BaseURL: "http://sidecar/api/", UnixSocket: socket},
+			want:   "/api/v1/status",
+		},
+		{
+			name:   "socket without base URL",
+			config: rest.Config{UnixSocket: socket},
+			want:   "/v1/status",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := rest.NewClient(tt.config)
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			var path string
+			if err := client.Do(context.Background(), http.MethodGet, "v1/status", nil, nil, &path); err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			if path != tt.want {
+				t.Errorf("Expected path %q, got %q", tt.want, path)
+			}
+		})
+	}
+}
+
+func TestUnixSocket_EmptyPath(t *testing.T) {
+	_, err := rest.NewClient(rest.Config{BaseURL: "unix://"})
+	if !errors.Is(err, rest.ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig, got %v", err)
+	}
+}
+
+func TestProxy(t *testing.T) {
+	var (
+		gotURL  string
+		gotAuth string
+	)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURL = r.URL.String()
+		gotAuth = r.Header.Get("Proxy-Authorization")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	proxyURL.User = url.UserPassword("user", "secret")
+
+	client, err := rest.NewClient(rest.Config{
+		BaseURL: "http://upstream.invalid/api/",
+		Proxy:   proxyURL,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	if err := client.Do(context.Background(), http.MethodGet, "items", nil, nil, nil); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	if gotURL != "http://upstream.invalid/api/items" {
+		t.Errorf("Expected proxied URL %q, got %q", "http://upstream.invalid/api/items", gotURL)
+	}
+	wantAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:secret"))
+	if gotAuth != wantAuth {
+		t.Errorf("Expected Proxy-Authorization %q, got %q", wantAuth, gotAuth)
+	}
+}
+
+func TestProxy_IgnoredWithCustomClient(t *testing.T) {
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer httpServer.Close()
+
+	client, _ := rest.NewClient(rest.Config{
+		Client:  &http.Client{},
+		BaseURL: httpServer.URL,
+		Proxy:   &url.URL{Scheme: "http", Host: "127.0.0.1:1"},
+	})
+
+	if err := client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil); err != nil {
+		t.Errorf("Do() error = %v", err)
+	}
+}
+
+func TestTransportTimeouts(t *testing.T) {
+	// A listener that accepts connections but never answers.
+	silent, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer silent.Close()
+	go func() {
+		for {
+			conn, err := silent.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _ = io.Copy(io.Discard, conn)
+				conn.Close()
+			}()
+		}
+	}()
+
+	release := make(chan struct{})
+	slowHeaders := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer slowHeaders.Close()
+	defer close(release)
+
+	tests := []struct {
+		name       string
+		config     rest.Config
+		wantMsg    string
+		mayBeReset bool
+	}{
+		{
+			name:    "TLS handshake",
+			config:  rest.Config{BaseURL: "https://" + silent.Addr().String(), TLSHandshakeTimeout: 50 * time.Millisecond},
+			wantMsg: "TLS handshake timeout",
+		},
+		{
+			name:    "Response headers",
+			config:  rest.Config{BaseURL: slowHeaders.URL, ResponseHeaderTimeout: 50 * time.Millisecond},
+			wantMsg: "timeout awaiting response headers",
+		},
+		{
+			// Connections to a non-routable address hang until the dial timeout,
+			// unless the network rejects them right away.
+			name:       "Dial",
+			config:     rest.Config{BaseURL: "http://10.255.255.1", DialTimeout: 50 * time.Millisecond},
+			wantMsg:    "i/o timeout",
+			mayBeReset: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := rest.NewClient(tt.config)
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			start := time.Now()
+			err = client.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+			if tt.mayBeReset && err != nil && !rest.IsTimeoutError(err) {
+				t.Skipf("Network does not allow testing dial timeouts: %v", err)
+			}
+			if !rest.IsTimeoutError(err) || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Fatalf("Expected %q timeout, got %v", tt.wantMsg, err)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("Expected the timeout to fire quickly, took %v", elapsed)
+			}
+		})
+	}
+}
+
+func TestMaxResponseHeaderBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Padding", strings.Repeat("x", 8<<10))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	limited, _ := rest.NewClient(rest.Config{BaseURL: server.URL, MaxResponseHeaderBytes: 4 << 10})
+	err := limited.Do(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	if !rest.IsInfrastructureError(err) || !strings.Contains(err.Error(), "headers exceeded") {
+		t.Errorf("Expected oversized headers to be rejected, got %v", err)
+	}
+
+	unlimited, _ := rest.NewClient(rest.Config{BaseURL: server.URL})
+	if err := unlimited.Do(context.Background(), http.MethodGet, "/", nil, nil, nil); err != nil {
+		t.Errorf("Do() error = %v", err)
+	}
+
+	custom, _ := rest.NewClient(rest.Config{BaseURL: server.URL, Client: &http.Client{}, MaxResponseHeaderBytes: 4 << 10})
+	if err := custom.Do(context.Background(), http.MethodGet, "/", nil, nil, nil); err != nil {
+		t.Errorf("Expected the limit to be ignored with a custom client, got %v", err)
+	}
+}